@@ -2,9 +2,11 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"go/ast"
 	"log"
 	"os"
 	"path/filepath"
@@ -15,17 +17,91 @@ import (
 )
 
 func main() {
+	// --- Subcommands ---
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "index":
+			runIndex(os.Args[2:])
+			return
+		case "path":
+			runPath(os.Args[2:])
+			return
+		case "surface":
+			runSurface(os.Args[2:])
+			return
+		case "impact":
+			runImpact(os.Args[2:])
+			return
+		}
+	}
+
 	// --- CLI Parameter Setup ---
 	projectPath := flag.String("p", "", "Project root directory (required)")
-	inputFile := flag.String("i", "", "Input file path (required)")
-	targetFunc := flag.String("t", "", "Target function/method name (required)")
+	inputFile := flag.String("i", "", "Input file path, relative to the project root or absolute (optional when -t is a symbol path such as pkgpath.(*Type).Method)")
+	targetFunc := flag.String("t", "", "Target function/method name, optionally qualified, e.g. Get, UserRepo.Get, (*UserRepo).Get or pkgpath.Func (required)")
 	outputFile := flag.String("o", "analysis_result.txt", "Output file for the result")
+	line := flag.Int("line", 0, "Line of a function literal inside -t to analyze instead of the whole function (e.g. a handler closure or goroutine body)")
 	deep := flag.Int("deep", 0, "Recursion depth for analysis (0 means no recursion)")
+	mode := flag.String("mode", "report", "Analysis mode: report or callers")
+	format := flag.String("format", tracer.FormatText, "Report format: text, json, dot or mermaid")
+	includeEdges := flag.Bool("edges", false, "Include caller -> callee call edges in the report")
+	methods := flag.String("methods", "", "Attach the methods of referenced types: signatures or full (default: none)")
+	typeDepth := flag.Int("type-depth", 0, "Levels of type dependencies (fields, elements, method signatures) to add to referenced types (0 disables)")
+	resolveInterfaces := flag.Bool("resolve-interfaces", false, "Resolve interface method calls to all concrete project implementations")
+	external := flag.String("external", "", "Record calls into the standard library and dependencies: leaf, or trace to follow their source (default: none)")
+	externalDepth := flag.Int("external-depth", 1, "Levels of dependency functions to trace into with -external trace")
+	externalAllow := flag.String("external-allow", "", "Comma-separated dependency package patterns to follow, e.g. net/http,github.com/jackc/pgx/... (default: all)")
+	externalDeny := flag.String("external-deny", "", "Comma-separated dependency package patterns never to follow, e.g. fmt,runtime/...")
+	snippets := flag.String("snippets", "", "Show less function source: skeleton (doc comments and signatures only) or hybrid (target in full, dependencies as signatures) (default: full)")
+	stripComments := flag.Bool("strip-comments", false, "Leave doc, field and inline comments out of snippets for more compact output")
+	maxBytes := flag.Int("max-bytes", 0, "Trim dependency sources, farthest first, to keep the report under this many bytes (0 means unlimited)")
+	maxTokens := flag.Int("max-tokens", 0, "Like -max-bytes, counted in tokens estimated at 4 bytes each")
+	order := flag.String("order", string(tracer.OrderPosition), "Order of functions, types and variables: position (package, file, line) or discovery (breadth-first)")
+	useIndex := flag.Bool("use-index", false, "Answer from the persistent project index instead of type-checking the project; lists names only, in text or json")
 	flag.Parse()
 
-	if *projectPath == "" || *inputFile == "" || *targetFunc == "" {
+	if *projectPath == "" || *targetFunc == "" {
 		flag.Usage()
-		log.Fatal("Error: -p, -i, and -t are required arguments.")
+		log.Fatal("Error: -p and -t are required arguments.")
+	}
+
+	switch tracer.MethodMode(*methods) {
+	case tracer.MethodsNone, tracer.MethodsSignatures, tracer.MethodsFull:
+	default:
+		log.Fatalf("Error: unknown -methods value: %s", *methods)
+	}
+	switch tracer.ExternalMode(*external) {
+	case tracer.ExternalNone, tracer.ExternalLeaf, tracer.ExternalTrace:
+	default:
+		log.Fatalf("Error: unknown -external value: %s", *external)
+	}
+	switch tracer.SnippetMode(*snippets) {
+	case tracer.SnippetsFull, tracer.SnippetsSkeleton, tracer.SnippetsHybrid:
+	default:
+		log.Fatalf("Error: unknown -snippets value: %s", *snippets)
+	}
+	switch tracer.OrderMode(*order) {
+	case tracer.OrderPosition, tracer.OrderDiscovery:
+	default:
+		log.Fatalf("Error: unknown -order value: %s", *order)
+	}
+
+	if *mode == "callers" && *format != tracer.FormatText && *format != tracer.FormatJSON {
+		log.Fatalf("Error: -format %s is not available with -mode callers", *format)
+	}
+	if *useIndex {
+		switch {
+		case *format != tracer.FormatText && *format != tracer.FormatJSON:
+			log.Fatalf("Error: -format %s is not available with -use-index", *format)
+		case *resolveInterfaces:
+			log.Fatal("Error: -resolve-interfaces is not available with -use-index")
+		case *typeDepth > 0:
+			log.Fatal("Error: -type-depth is not available with -use-index")
+		case *methods != "":
+			log.Fatal("Error: -methods is not available with -use-index")
+		case *external != "":
+			log.Fatal("Error: -external is not available with -use-index")
+		}
 	}
 
 	// --- Path Handling ---
@@ -33,15 +109,174 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error resolving project path: %v", err)
 	}
-	var absInputFile string
-	if filepath.IsAbs(*inputFile) {
-		absInputFile = filepath.Clean(*inputFile)
-	} else {
-		absInputFile = filepath.Join(absProjectPath, *inputFile)
+
+	if *useIndex {
+		report := indexedReport(absProjectPath, *inputFile, *targetFunc, *line, *deep, *mode, *format, tracer.OrderMode(*order))
+		writeOutput(*outputFile, report)
+		return
 	}
 
 	// --- Load Project ---
-	fmt.Printf("Loading project from: %s\n", absProjectPath)
+	pkgs := loadPackages(absProjectPath)
+
+	// --- Find Initial Target ---
+	initialTarget, absInputFile := findTarget(pkgs, absProjectPath, *inputFile, *targetFunc, *line)
+
+	// --- Perform Analysis by calling the tracer package ---
+	opts := tracer.Options{
+		ResolveInterfaces: *resolveInterfaces,
+		IncludeEdges:      *includeEdges,
+		Format:            *format,
+		TypeDepth:         *typeDepth,
+		Methods:           tracer.MethodMode(*methods),
+		External:          tracer.ExternalMode(*external),
+		ExternalDepth:     *externalDepth,
+		ExternalAllow:     tracer.ParsePackagePatterns(*externalAllow),
+		ExternalDeny:      tracer.ParsePackagePatterns(*externalDeny),
+		Order:             tracer.OrderMode(*order),
+		MaxBytes:          *maxBytes,
+		MaxTokens:         *maxTokens,
+		Snippets:          tracer.SnippetMode(*snippets),
+		StripComments:     *stripComments,
+	}
+	var report string
+	switch *mode {
+	case "report":
+		report, err = tracer.Analyze(initialTarget, absInputFile, *deep, pkgs, opts)
+		if err != nil {
+			log.Fatalf("Analysis failed: %v", err)
+		}
+	case "callers":
+		callers, err := tracer.FindCallers(initialTarget, *deep, pkgs, opts)
+		if err != nil {
+			log.Fatalf("Caller analysis failed: %v", err)
+		}
+		targetName := tracer.TargetName(initialTarget)
+		if *format == tracer.FormatJSON {
+			if callers == nil {
+				callers = []string{}
+			}
+			data, err := json.MarshalIndent(callersResult{Function: targetName, File: absInputFile, Depth: *deep, Callers: callers}, "", "  ")
+			if err != nil {
+				log.Fatalf("Error encoding result: %v", err)
+			}
+			report = string(data) + "\n"
+			break
+		}
+		report = fmt.Sprintf("Callers of Function: %s (depth=%d)\n", targetName, *deep)
+		report += fmt.Sprintf("Defined in: %s\n\n", absInputFile)
+		if len(callers) == 0 {
+			report += "- None\n"
+		}
+		for _, name := range callers {
+			report += fmt.Sprintf("- %s\n", name)
+		}
+	default:
+		log.Fatalf("unknown mode: %s", *mode)
+	}
+
+	// --- Write Report ---
+	writeOutput(*outputFile, report)
+}
+
+// callersResult is the JSON form of the callers mode.
+type callersResult struct {
+	Function string   `json:"function"`
+	File     string   `json:"file"`
+	Depth    int      `json:"depth"`
+	Callers  []string `json:"callers"`
+}
+
+// writeOutput writes the result of the default command to outputFile.
+func writeOutput(outputFile, report string) {
+	if err := os.WriteFile(outputFile, []byte(report), 0644); err != nil {
+		log.Fatalf("Error writing to output file: %v", err)
+	}
+	fmt.Printf("Analysis complete. Results written to %s\n", outputFile)
+}
+
+// indexedReport answers the report and callers modes from the project index,
+// refreshing it first. Only function and type names are listed.
+func indexedReport(absProjectPath, inputFile, funcName string, line, depth int, mode, format string, order tracer.OrderMode) string {
+	indexFile := tracer.DefaultIndexPath(absProjectPath)
+	idx, err := tracer.LoadIndex(indexFile, absProjectPath)
+	if err != nil {
+		log.Fatalf("Error reading index: %v", err)
+	}
+	updated, err := idx.Update(context.Background())
+	if err != nil {
+		log.Fatalf("Indexing failed: %v", err)
+	}
+	if len(updated) > 0 {
+		if err := idx.Save(indexFile); err != nil {
+			log.Fatalf("Error writing index: %v", err)
+		}
+	}
+
+	if inputFile != "" {
+		inputFile = tracer.ResolveFile(absProjectPath, inputFile)
+	}
+	fn, err := idx.FindFunc(inputFile, funcName)
+	if err == nil && line != 0 {
+		fn, err = idx.FindClosure(fn, line)
+	}
+	if err != nil {
+		log.Fatalf("Error finding target: %v", err)
+	}
+
+	result := struct {
+		Function  string   `json:"function"`
+		File      string   `json:"file"`
+		Depth     int      `json:"depth"`
+		Callers   []string `json:"-"`
+		Functions []string `json:"functions"`
+		Types     []string `json:"types"`
+	}{Function: fn.Name, File: fn.File, Depth: depth}
+	type section struct {
+		Title string
+		Names []string
+	}
+	var sections []section
+	switch mode {
+	case "report":
+		result.Functions = idx.CalledFuncs(fn.Name, depth, order)
+		result.Types = idx.ReferencedTypes(fn.Name, depth, order)
+		sections = []section{{"Called Functions/Methods", result.Functions}, {"Referenced Types", result.Types}}
+	case "callers":
+		result.Callers = idx.Callers(fn.Name, depth)
+		sections = []section{{"Callers", result.Callers}}
+	default:
+		log.Fatalf("unknown mode: %s", mode)
+	}
+
+	if format == tracer.FormatJSON {
+		var value any = result
+		if mode == "callers" {
+			value = callersResult{Function: fn.Name, File: fn.File, Depth: depth, Callers: append([]string{}, result.Callers...)}
+		}
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding result: %v", err)
+		}
+		return string(data) + "\n"
+	}
+	report := fmt.Sprintf("Analysis for Function: %s (depth=%d, from index)\n", fn.Name, depth)
+	report += fmt.Sprintf("Defined in: %s\n", fn.File)
+	for _, section := range sections {
+		report += fmt.Sprintf("\n%s:\n", section.Title)
+		if len(section.Names) == 0 {
+			report += "- None\n"
+		}
+		for _, name := range section.Names {
+			report += fmt.Sprintf("- %s\n", name)
+		}
+	}
+	return report
+}
+
+// loadPackages loads every package of the project, exiting on load or type errors.
+func loadPackages(absProjectPath string) []*packages.Package {
+	log.Printf("Loading project from: %s", absProjectPath)
 	cfg := &packages.Config{Mode: packages.LoadSyntax | packages.LoadTypes | packages.LoadFiles, Dir: absProjectPath}
 	pkgs, err := packages.Load(cfg, "./...")
 	if err != nil {
@@ -50,42 +285,243 @@ func main() {
 	if packages.PrintErrors(pkgs) > 0 {
 		log.Fatalf("Errors found while loading packages.")
 	}
+	return pkgs
+}
 
-	// --- Find Initial Target ---
-	var initialTarget tracer.AnalysisTarget
-	for _, p := range pkgs {
-		for i, file := range p.GoFiles {
-			if file == absInputFile {
-				for _, decl := range p.Syntax[i].Decls {
-					if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == *targetFunc {
-						// Note that we are creating a tracer.AnalysisTarget struct
-						initialTarget = tracer.AnalysisTarget{Pkg: p, Fn: fn}
-						break
-					}
-				}
-			}
-			if initialTarget.Fn != nil {
-				break
-			}
-		}
-		if initialTarget.Fn != nil {
-			break
+// findTarget locates a function by file and name, or by symbol path when no
+// file is given; a non-zero line selects the function literal starting on that
+// line inside it. It returns the target and the absolute path of its file.
+func findTarget(pkgs []*packages.Package, absProjectPath, inputFile, funcName string, line int) (tracer.AnalysisTarget, string) {
+	var target tracer.AnalysisTarget
+	var err error
+	absInputFile := ""
+	if inputFile != "" {
+		absInputFile = tracer.ResolveFile(absProjectPath, inputFile)
+		target, err = tracer.FindTarget(pkgs, absInputFile, funcName)
+	} else {
+		target, err = tracer.FindSymbol(pkgs, funcName)
+	}
+	if err == nil && line != 0 {
+		target, err = tracer.FindClosure(target, line)
+	}
+	if err != nil {
+		log.Fatalf("Error finding target: %v", err)
+	}
+	if absInputFile == "" {
+		absInputFile = tracer.TargetFile(target)
+	}
+	return target, absInputFile
+}
+
+// runPath prints the shortest call chains from one function to another.
+func runPath(args []string) {
+	fs := flag.NewFlagSet("path", flag.ExitOnError)
+	projectPath := fs.String("p", "", "Project root directory (required)")
+	fromFile := fs.String("i", "", "File containing the source function (optional when -from is a symbol path)")
+	fromFunc := fs.String("from", "", "Source function/method name (required)")
+	toFile := fs.String("to-file", "", "File containing the destination function (optional when -to is a symbol path)")
+	toFunc := fs.String("to", "", "Destination function/method name (required)")
+	fromLine := fs.Int("from-line", 0, "Line of a function literal inside -from to start from instead")
+	toLine := fs.Int("to-line", 0, "Line of a function literal inside -to to end at instead")
+	k := fs.Int("k", 1, "Number of shortest paths to return")
+	maxHops := fs.Int("max-hops", 10, "Maximum number of calls in a path")
+	resolveInterfaces := fs.Bool("resolve-interfaces", false, "Resolve interface method calls to all concrete project implementations")
+	outputFile := fs.String("o", "", "Output file for the result (default: standard output)")
+	fs.Parse(args)
+
+	if *projectPath == "" || *fromFunc == "" || *toFunc == "" {
+		fs.Usage()
+		log.Fatal("Error: -p, -from and -to are required arguments.")
+	}
+	absProjectPath, err := filepath.Abs(*projectPath)
+	if err != nil {
+		log.Fatalf("Error resolving project path: %v", err)
+	}
+
+	pkgs := loadPackages(absProjectPath)
+	from, _ := findTarget(pkgs, absProjectPath, *fromFile, *fromFunc, *fromLine)
+	to, _ := findTarget(pkgs, absProjectPath, *toFile, *toFunc, *toLine)
+
+	opts := tracer.Options{ResolveInterfaces: *resolveInterfaces}
+	paths, err := tracer.FindCallPaths(from, to, *k, *maxHops, pkgs, opts)
+	if err != nil && !errors.Is(err, tracer.ErrTruncated) {
+		log.Fatalf("Path search failed: %v", err)
+	}
+
+	report := fmt.Sprintf("Call paths from %s to %s (max-hops=%d)\n", *fromFunc, *toFunc, *maxHops)
+	if err != nil {
+		report += fmt.Sprintf("Partial result: %v\n", err)
+	}
+	if len(paths) == 0 {
+		report += "\n- None\n"
+	}
+	for i, path := range paths {
+		report += fmt.Sprintf("\nPath %d (%d hops):\n%s", i+1, len(path.Hops), path)
+	}
+
+	if *outputFile == "" {
+		fmt.Print(report)
+		return
+	}
+	if err := os.WriteFile(*outputFile, []byte(report), 0644); err != nil {
+		log.Fatalf("Error writing to output file: %v", err)
+	}
+	fmt.Printf("Path search complete. Results written to %s\n", *outputFile)
+}
+
+// runSurface prints the dependency symbols a function relies on, grouped by module.
+func runSurface(args []string) {
+	fs := flag.NewFlagSet("surface", flag.ExitOnError)
+	projectPath := fs.String("p", "", "Project root directory (required)")
+	inputFile := fs.String("i", "", "File containing the target function (optional when -t is a symbol path)")
+	targetFunc := fs.String("t", "", "Target function/method name (required)")
+	line := fs.Int("line", 0, "Line of a function literal inside -t to analyze instead")
+	deep := fs.Int("deep", 3, "Depth of project calls to follow")
+	format := fs.String("format", tracer.FormatText, "Output format: text or json")
+	externalAllow := fs.String("external-allow", "", "Comma-separated dependency package patterns to report, e.g. github.com/aws/aws-sdk-go/service/s3/... (default: all)")
+	externalDeny := fs.String("external-deny", "", "Comma-separated dependency package patterns to leave out, e.g. fmt,errors")
+	outputFile := fs.String("o", "", "Output file for the result (default: standard output)")
+	fs.Parse(args)
+
+	if *projectPath == "" || *targetFunc == "" {
+		fs.Usage()
+		log.Fatal("Error: -p and -t are required arguments.")
+	}
+	if *format != tracer.FormatText && *format != tracer.FormatJSON {
+		log.Fatalf("Error: unknown -format value: %s", *format)
+	}
+	absProjectPath, err := filepath.Abs(*projectPath)
+	if err != nil {
+		log.Fatalf("Error resolving project path: %v", err)
+	}
+
+	pkgs := loadPackages(absProjectPath)
+	target, _ := findTarget(pkgs, absProjectPath, *inputFile, *targetFunc, *line)
+
+	opts := tracer.Options{
+		ExternalAllow: tracer.ParsePackagePatterns(*externalAllow),
+		ExternalDeny:  tracer.ParsePackagePatterns(*externalDeny),
+	}
+	surface, err := tracer.BuildDependencySurface(target, *deep, pkgs, opts)
+	if err != nil {
+		log.Fatalf("Dependency surface failed: %v", err)
+	}
+
+	report := surface.String()
+	if *format == tracer.FormatJSON {
+		data, err := json.MarshalIndent(surface, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding result: %v", err)
 		}
+		report = string(data) + "\n"
 	}
-	if initialTarget.Fn == nil {
-		log.Fatalf("Function '%s' not found in file '%s'", *targetFunc, absInputFile)
+
+	if *outputFile == "" {
+		fmt.Print(report)
+		return
 	}
+	if err := os.WriteFile(*outputFile, []byte(report), 0644); err != nil {
+		log.Fatalf("Error writing to output file: %v", err)
+	}
+	fmt.Printf("Dependency surface complete. Results written to %s\n", *outputFile)
+}
 
-	// --- Perform Analysis by calling the tracer package ---
-	report, err := tracer.Analyze(initialTarget, absInputFile, *deep, pkgs)
+// runImpact reports the functions and entry points affected by the changes
+// made since a base revision.
+func runImpact(args []string) {
+	fs := flag.NewFlagSet("impact", flag.ExitOnError)
+	projectPath := fs.String("p", "", "Project root directory (required)")
+	base := fs.String("base", "main", "Branch or revision to compare against; changes since its merge base with HEAD are analyzed")
+	roots := fs.String("roots", "", "Comma-separated entry point kinds to report: main, exported, http (default: all)")
+	resolveInterfaces := fs.Bool("resolve-interfaces", false, "Trace callers through interface method calls")
+	format := fs.String("format", tracer.FormatText, "Output format: text or json")
+	outputFile := fs.String("o", "", "Output file for the result (default: standard output)")
+	fs.Parse(args)
+
+	if *projectPath == "" {
+		fs.Usage()
+		log.Fatal("Error: -p is a required argument.")
+	}
+	if *format != tracer.FormatText && *format != tracer.FormatJSON {
+		log.Fatalf("Error: unknown -format value: %s", *format)
+	}
+	rootKinds, err := tracer.ParseRootKinds(*roots)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	absProjectPath, err := filepath.Abs(*projectPath)
 	if err != nil {
-		log.Fatalf("Analysis failed: %v", err)
+		log.Fatalf("Error resolving project path: %v", err)
 	}
 
-	// --- Write Report ---
-	err = os.WriteFile(*outputFile, []byte(report), 0644)
+	changes, err := tracer.ChangedLines(context.Background(), absProjectPath, *base)
 	if err != nil {
+		log.Fatalf("Error reading changes: %v", err)
+	}
+	pkgs := loadPackages(absProjectPath)
+	opts := tracer.Options{ResolveInterfaces: *resolveInterfaces}
+	impact, err := tracer.AnalyzeImpact(*base, changes, rootKinds, pkgs, opts)
+	if err != nil {
+		log.Fatalf("Impact analysis failed: %v", err)
+	}
+
+	report := impact.String()
+	if *format == tracer.FormatJSON {
+		data, err := json.MarshalIndent(impact, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding result: %v", err)
+		}
+		report = string(data) + "\n"
+	}
+
+	if *outputFile == "" {
+		fmt.Print(report)
+		return
+	}
+	if err := os.WriteFile(*outputFile, []byte(report), 0644); err != nil {
 		log.Fatalf("Error writing to output file: %v", err)
 	}
-	fmt.Printf("Analysis complete. Results written to %s\n", *outputFile)
+	fmt.Printf("Impact analysis complete. Results written to %s\n", *outputFile)
+}
+
+// runIndex builds or incrementally refreshes the on-disk project index.
+func runIndex(args []string) {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	projectPath := fs.String("p", "", "Project root directory (required)")
+	indexFile := fs.String("o", "", "Index file (default: <project>/.gct/index.json)")
+	rebuild := fs.Bool("rebuild", false, "Discard the existing index and re-index every package")
+	fs.Parse(args)
+
+	if *projectPath == "" {
+		fs.Usage()
+		log.Fatal("Error: -p is a required argument.")
+	}
+	absProjectPath, err := filepath.Abs(*projectPath)
+	if err != nil {
+		log.Fatalf("Error resolving project path: %v", err)
+	}
+	if *indexFile == "" {
+		*indexFile = tracer.DefaultIndexPath(absProjectPath)
+	}
+
+	idx := &tracer.Index{Version: tracer.IndexVersion, Project: absProjectPath, Packages: map[string]*tracer.PackageIndex{}}
+	if !*rebuild {
+		idx, err = tracer.LoadIndex(*indexFile, absProjectPath)
+		if err != nil {
+			log.Fatalf("Error reading index: %v", err)
+		}
+	}
+
+	fmt.Printf("Indexing project: %s\n", absProjectPath)
+	updated, err := idx.Update(context.Background())
+	if err != nil {
+		log.Fatalf("Indexing failed: %v", err)
+	}
+	for _, pkgPath := range updated {
+		fmt.Printf("- re-indexed %s\n", pkgPath)
+	}
+	if err := idx.Save(*indexFile); err != nil {
+		log.Fatalf("Error writing index: %v", err)
+	}
+	fmt.Printf("Index complete: %d of %d package(s) updated. Index written to %s\n", len(updated), len(idx.Packages), *indexFile)
 }
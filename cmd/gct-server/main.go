@@ -17,13 +17,22 @@ func main() {
 	mode := flag.String("mode", "stdio", "Transport mode: stdio or sse")
 	addr := flag.String("addr", ":8080", "HTTP listen address for SSE")
 	path := flag.String("path", "/mcp/sse", "HTTP path for SSE connections")
+	timeout := flag.Duration("timeout", 0, "Time limit for each tool call, after which a partial result is returned (0 for no limit)")
+	toolTimeouts := flag.String("tool-timeouts", "", "Per-tool time limits overriding -timeout, e.g. 'full_report=2m,call_path=30s'")
 	flag.Parse()
 
+	perTool, err := handlers.ParseToolTimeouts(*toolTimeouts)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
 	// Create a new MCP server
 	s := server.NewMCPServer(
 		"Go Code Tracer 🚀",
 		"1.0.0",
 		server.WithToolCapabilities(false),
+		server.WithToolHandlerMiddleware(handlers.TimeoutMiddleware(handlers.Timeouts{Default: *timeout, PerTool: perTool})),
+		server.WithToolHandlerMiddleware(handlers.ProgressMiddleware),
 	)
 
 	// Register all tools and their corresponding handlers
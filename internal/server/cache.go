@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-call-tracer/internal/tracer"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadMode is the package loading mode used by every tool.
+const loadMode = packages.LoadSyntax | packages.LoadTypes | packages.LoadFiles
+
+// parseProgressInterval is how many parsed files lie between two progress
+// updates while a project is type-checked.
+const parseProgressInterval = 200
+
+// maxCachedProjects is how many loaded projects the shared cache keeps; the
+// least recently used one is dropped to make room for another.
+const maxCachedProjects = 8
+
+// fingerprintInterval is how long cached packages are used without checking
+// the project's files for changes again.
+const fingerprintInterval = 2 * time.Second
+
+// buildEnvVars are the environment variables that change how packages are loaded
+// and therefore take part in the cache key.
+var buildEnvVars = []string{"GOOS", "GOARCH", "GOFLAGS", "CGO_ENABLED", "GOWORK"}
+
+// cacheKey identifies a loaded project together with its build configuration.
+type cacheKey struct {
+	Dir  string
+	Mode packages.LoadMode
+	Env  string
+}
+
+// cacheEntry holds the packages loaded for one cache key, the dependency
+// sources loaded for them and the fingerprint of the source files they were
+// loaded from.
+type cacheEntry struct {
+	mu          sync.Mutex
+	pkgs        []*packages.Package
+	deps        *tracer.DependencyCache
+	fingerprint string
+	checked     time.Time // When the fingerprint was last compared.
+	lastUsed    time.Time // Guarded by projectCache.mu.
+}
+
+// projectCache keeps loaded packages per project across tool calls. An entry is
+// reloaded when any Go file, go.mod, go.sum or go.work file under the project
+// is added, removed or modified.
+type projectCache struct {
+	mu       sync.Mutex
+	entries  map[cacheKey]*cacheEntry
+	limit    int           // Most entries kept; 0 means no limit.
+	interval time.Duration // Minimum time between two fingerprints of an entry.
+}
+
+// packageCache is the cache shared by all tool handlers.
+var packageCache = newProjectCache(maxCachedProjects, fingerprintInterval)
+
+func newProjectCache(limit int, interval time.Duration) *projectCache {
+	return &projectCache{entries: make(map[cacheKey]*cacheEntry), limit: limit, interval: interval}
+}
+
+// Load returns the packages of the project at projectPath, loading them only if
+// they are not cached or the project's files changed since they were loaded.
+// Cancelling ctx stops a load in progress; nothing is cached for it.
+func (c *projectCache) Load(ctx context.Context, projectPath string) ([]*packages.Package, *tracer.DependencyCache, error) {
+	dir, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := cacheKey{Dir: filepath.Clean(dir), Mode: loadMode, Env: buildEnv()}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{}
+		c.entries[key] = entry
+	}
+	entry.lastUsed = time.Now()
+	c.evict()
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.pkgs != nil && time.Since(entry.checked) < c.interval {
+		log.Printf("Package cache hit for project: %s", key.Dir)
+		reportProgress(ctx, "Using cached packages of %s", key.Dir)
+		return entry.pkgs, entry.deps, nil
+	}
+	fingerprint, err := fingerprintProject(key.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case entry.pkgs == nil:
+		log.Printf("Package cache miss for project: %s", key.Dir)
+	case entry.fingerprint != fingerprint:
+		log.Printf("Package cache miss for project: %s (files changed)", key.Dir)
+	default:
+		entry.checked = time.Now()
+		log.Printf("Package cache hit for project: %s", key.Dir)
+		reportProgress(ctx, "Using cached packages of %s", key.Dir)
+		return entry.pkgs, entry.deps, nil
+	}
+
+	reportProgress(ctx, "Loading packages of %s", key.Dir)
+	var parsed atomic.Int64
+	cfg := &packages.Config{
+		Mode:    loadMode,
+		Dir:     key.Dir,
+		Context: ctx,
+		// Files are parsed as their packages are type-checked, so the count of
+		// parsed files shows how far type-checking has come.
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			if n := parsed.Add(1); n%parseProgressInterval == 1 {
+				reportProgress(ctx, "Type-checking packages: %d files parsed", n)
+			}
+			return parser.ParseFile(fset, filename, src, parser.AllErrors|parser.ParseComments)
+		},
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, nil, err
+	}
+	reportProgress(ctx, "Loaded %d packages (%d files)", len(pkgs), parsed.Load())
+	if packages.PrintErrors(pkgs) > 0 {
+		log.Printf("Errors found while loading packages for project: %s", key.Dir)
+	}
+	entry.pkgs = pkgs
+	entry.deps = tracer.NewDependencyCache()
+	entry.fingerprint = fingerprint
+	entry.checked = time.Now()
+	return pkgs, entry.deps, nil
+}
+
+// evict drops the least recently used entries until the cache is within its
+// limit. Callers still holding a dropped entry can keep using it. c.mu must be
+// held.
+func (c *projectCache) evict() {
+	for c.limit > 0 && len(c.entries) > c.limit {
+		var oldest cacheKey
+		var oldestEntry *cacheEntry
+		for key, entry := range c.entries {
+			if oldestEntry == nil || entry.lastUsed.Before(oldestEntry.lastUsed) {
+				oldest, oldestEntry = key, entry
+			}
+		}
+		delete(c.entries, oldest)
+		log.Printf("Dropped cached packages of project: %s", oldest.Dir)
+	}
+}
+
+// buildEnv returns the build-relevant environment as a single string.
+func buildEnv() string {
+	var parts []string
+	for _, name := range buildEnvVars {
+		parts = append(parts, name+"="+os.Getenv(name))
+	}
+	return strings.Join(parts, ";")
+}
+
+// fingerprintProject hashes the path, size and modification time of every file
+// under dir that affects package loading. Directories ignored by the go tool
+// (testdata and names starting with "." or "_") are skipped.
+func fingerprintProject(dir string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") && name != "go.mod" && name != "go.sum" && name != "go.work" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeModule creates a module with a single package in a new directory.
+func writeModule(t *testing.T, source string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "m.go"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestProjectCacheEviction(t *testing.T) {
+	cache := newProjectCache(1, 0)
+	first := writeModule(t, "package m\n\nfunc A() {}\n")
+	second := writeModule(t, "package m\n\nfunc B() {}\n")
+	for _, dir := range []string{first, second} {
+		if _, _, err := cache.Load(context.Background(), dir); err != nil {
+			t.Fatalf("Load(%s): %v", dir, err)
+		}
+	}
+	if len(cache.entries) != 1 {
+		t.Fatalf("cache holds %d entries, want 1", len(cache.entries))
+	}
+	for key := range cache.entries {
+		if key.Dir != filepath.Clean(second) {
+			t.Errorf("cache kept %s, want the most recently used %s", key.Dir, second)
+		}
+	}
+}
+
+func TestProjectCacheFingerprintInterval(t *testing.T) {
+	dir := writeModule(t, "package m\n\nfunc A() {}\n")
+	change := func(source string) {
+		if err := os.WriteFile(filepath.Join(dir, "m.go"), []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	throttled := newProjectCache(0, time.Hour)
+	before, _, err := throttled.Load(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change("package m\n\nfunc A() {}\n\nfunc B() {}\n")
+	after, _, err := throttled.Load(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after[0] != before[0] {
+		t.Error("packages were reloaded within the fingerprint interval")
+	}
+
+	unthrottled := newProjectCache(0, 0)
+	before, _, err = unthrottled.Load(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change("package m\n\nfunc A() {}\n")
+	after, _, err = unthrottled.Load(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after[0] == before[0] {
+		t.Error("packages were not reloaded after the project changed")
+	}
+}
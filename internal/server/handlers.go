@@ -1,8 +1,10 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"go-call-tracer/internal/tracer"
-	"log"
+	"go/types"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -13,16 +15,86 @@ import (
 )
 
 // loadProject is a shared helper that loads Go packages from a project path.
-func loadProject(projectPath string) ([]*packages.Package, error) {
-	cfg := &packages.Config{Mode: packages.LoadSyntax | packages.LoadTypes | packages.LoadFiles, Dir: projectPath}
-	pkgs, err := packages.Load(cfg, "./...")
-	if err != nil {
-		return nil, err
+// Packages are cached per project and reused until the project's files change,
+// together with the dependency sources loaded for them.
+func loadProject(ctx context.Context, projectPath string) ([]*packages.Package, *tracer.DependencyCache, error) {
+	return packageCache.Load(ctx, projectPath)
+}
+
+// findTarget locates the requested function. If a file is given it is resolved
+// against the project root and searched; otherwise funcName is looked up as a
+// symbol path across all loaded packages. A non-zero line selects the function
+// literal starting on that line inside the function instead.
+func findTarget(pkgs []*packages.Package, project, file, funcName string, line int) (tracer.AnalysisTarget, error) {
+	var target tracer.AnalysisTarget
+	var err error
+	if file == "" {
+		target, err = tracer.FindSymbol(pkgs, funcName)
+	} else {
+		target, err = tracer.FindTarget(pkgs, tracer.ResolveFile(project, file), funcName)
+	}
+	if err != nil || line == 0 {
+		return target, err
+	}
+	return tracer.FindClosure(target, line)
+}
+
+// optionsFromRequest builds the tracer options from the optional tool arguments,
+// rejecting unknown mode values the way the CLI does. The analysis stops when
+// ctx is cancelled or reaches its deadline.
+func optionsFromRequest(ctx context.Context, request mcp.CallToolRequest) (tracer.Options, error) {
+	opts := tracer.Options{
+		Context:           ctx,
+		Progress:          progressFunc(ctx),
+		ResolveInterfaces: request.GetBool("resolve_interfaces", false),
+		IncludeEdges:      request.GetBool("include_edges", false),
+		Format:            request.GetString("format", tracer.FormatText),
+		TypeDepth:         request.GetInt("type_depth", 0),
+		Methods:           tracer.MethodMode(request.GetString("methods", "")),
+		External:          tracer.ExternalMode(request.GetString("external", "")),
+		ExternalDepth:     request.GetInt("external_depth", 1),
+		ExternalAllow:     tracer.ParsePackagePatterns(request.GetString("external_allow", "")),
+		ExternalDeny:      tracer.ParsePackagePatterns(request.GetString("external_deny", "")),
+		Order:             tracer.OrderMode(request.GetString("order", string(tracer.OrderPosition))),
+		MaxBytes:          request.GetInt("max_bytes", 0),
+		MaxTokens:         request.GetInt("max_tokens", 0),
+		Snippets:          tracer.SnippetMode(request.GetString("snippets", "")),
+		StripComments:     request.GetBool("strip_comments", false),
+	}
+	switch opts.Methods {
+	case tracer.MethodsNone, tracer.MethodsSignatures, tracer.MethodsFull:
+	default:
+		return opts, fmt.Errorf("unknown methods value '%s'; expected signatures or full", opts.Methods)
 	}
-	if packages.PrintErrors(pkgs) > 0 {
-		log.Printf("Errors found while loading packages for project: %s", projectPath)
+	switch opts.External {
+	case tracer.ExternalNone, tracer.ExternalLeaf, tracer.ExternalTrace:
+	default:
+		return opts, fmt.Errorf("unknown external value '%s'; expected leaf or trace", opts.External)
 	}
-	return pkgs, nil
+	switch opts.Order {
+	case tracer.OrderPosition, tracer.OrderDiscovery:
+	default:
+		return opts, fmt.Errorf("unknown order value '%s'; expected position or discovery", opts.Order)
+	}
+	switch opts.Snippets {
+	case tracer.SnippetsFull, tracer.SnippetsSkeleton, tracer.SnippetsHybrid:
+	default:
+		return opts, fmt.Errorf("unknown snippets value '%s'; expected skeleton or hybrid", opts.Snippets)
+	}
+	return opts, nil
+}
+
+// structuredResult returns the structured result of a tool. A result cut short
+// by the request's deadline or cancellation, reported by an error wrapping
+// tracer.ErrTruncated, is returned as well, with the reason in its text and in
+// _meta.truncated. Reports carry the reason in their own truncated field.
+func structuredResult(value any, tool string, err error) *mcp.CallToolResult {
+	if err == nil {
+		return mcp.NewToolResultStructured(value, tool)
+	}
+	result := mcp.NewToolResultStructured(value, tool+" (truncated: "+err.Error()+")")
+	result.Meta = mcp.NewMetaFromMap(map[string]any{"truncated": err.Error()})
+	return result
 }
 
 // fullReportHandler handles requests for the 'full_report' tool.
@@ -31,30 +103,37 @@ func fullReportHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	file, err := request.RequireString("file")
+	file := request.GetString("file", "")
+	funcName, err := request.RequireString("func")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	funcName, err := request.RequireString("func")
+	line := request.GetInt("line", 0)
+	depth, err := request.RequireInt("depth")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	depth, err := request.RequireInt("depth")
+
+	opts, err := optionsFromRequest(ctx, request)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	pkgs, err := loadProject(project)
+	pkgs, deps, err := loadProject(ctx, project)
 	if err != nil {
 		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
 	}
+	opts.Dependencies = deps
 
-	target, err := tracer.FindTarget(pkgs, file, funcName)
+	target, err := findTarget(pkgs, project, file, funcName, line)
 	if err != nil {
 		return mcp.NewToolResultError("Failed to find target: " + err.Error()), nil
 	}
 
-	report, err := tracer.Analyze(target, file, int(depth), pkgs)
+	if file == "" {
+		file = tracer.TargetFile(target)
+	}
+	report, err := tracer.Analyze(target, file, int(depth), pkgs, opts)
 	if err != nil {
 		return mcp.NewToolResultError("Failed to analyze dependencies: " + err.Error()), nil
 	}
@@ -68,20 +147,18 @@ func funcCodeHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.Cal
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	file, err := request.RequireString("file")
-	if err != nil {
-		return mcp.NewToolResultError(err.Error()), nil
-	}
+	file := request.GetString("file", "")
 	funcName, err := request.RequireString("func")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	line := request.GetInt("line", 0)
 
-	pkgs, err := loadProject(project)
+	pkgs, _, err := loadProject(ctx, project)
 	if err != nil {
 		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
 	}
-	target, err := tracer.FindTarget(pkgs, file, funcName)
+	target, err := findTarget(pkgs, project, file, funcName, line)
 	if err != nil {
 		return mcp.NewToolResultError("Failed to find target: " + err.Error()), nil
 	}
@@ -99,34 +176,56 @@ func refTypesHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.Cal
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	file, err := request.RequireString("file")
-	if err != nil {
-		return mcp.NewToolResultError(err.Error()), nil
-	}
+	file := request.GetString("file", "")
 	funcName, err := request.RequireString("func")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	line := request.GetInt("line", 0)
 	// Use 3 as default if depth is not provided
 	depth, err := request.RequireInt("depth")
 	if err != nil {
 		depth = 3
 	}
 
-	pkgs, err := loadProject(project)
+	opts, err := optionsFromRequest(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if request.GetBool("use_index", false) {
+		if err := checkIndexOptions(opts); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		idx, fn, err := indexTarget(ctx, project, file, funcName, line)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to query index: " + err.Error()), nil
+		}
+		return mcp.NewToolResultStructured(idx.ReferencedTypes(fn.Name, int(depth), opts.Order), "ref_types"), nil
+	}
+
+	pkgs, deps, err := loadProject(ctx, project)
 	if err != nil {
 		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
 	}
-	target, err := tracer.FindTarget(pkgs, file, funcName)
+	opts.Dependencies = deps
+	target, err := findTarget(pkgs, project, file, funcName, line)
 	if err != nil {
 		return mcp.NewToolResultError("Failed to find target: " + err.Error()), nil
 	}
-	types, err := tracer.ExtractTypes(target, int(depth), pkgs)
-	if err != nil {
+	if opts.Methods != tracer.MethodsNone {
+		typeSymbols, err := tracer.ExtractTypeSymbols(target, int(depth), pkgs, opts)
+		if err != nil && !errors.Is(err, tracer.ErrTruncated) {
+			return mcp.NewToolResultError("Failed to extract types: " + err.Error()), nil
+		}
+		return structuredResult(typeSymbols, "ref_types", err), nil
+	}
+	types, err := tracer.ExtractTypes(target, int(depth), pkgs, opts)
+	if err != nil && !errors.Is(err, tracer.ErrTruncated) {
 		return mcp.NewToolResultError("Failed to extract types: " + err.Error()), nil
 	}
 
-	return mcp.NewToolResultStructured(types, "ref_types"), nil
+	return structuredResult(types, "ref_types", err), nil
 }
 
 // calledFuncsHandler handles requests for the 'called_funcs' tool.
@@ -135,48 +234,403 @@ func calledFuncsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	file, err := request.RequireString("file")
+	file := request.GetString("file", "")
+	funcName, err := request.RequireString("func")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	line := request.GetInt("line", 0)
+	// Use 0 as default if depth is not provided
+	depth, err := request.RequireInt("depth")
+	if err != nil {
+		depth = 3
+	}
+
+	opts, err := optionsFromRequest(ctx, request)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+
+	if request.GetBool("use_index", false) {
+		if err := checkIndexOptions(opts); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		idx, fn, err := indexTarget(ctx, project, file, funcName, line)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to query index: " + err.Error()), nil
+		}
+		if opts.IncludeEdges {
+			return mcp.NewToolResultStructured(idx.CallGraph(fn.Name, int(depth)), "called_funcs"), nil
+		}
+		return mcp.NewToolResultStructured(idx.CalledFuncs(fn.Name, int(depth), opts.Order), "called_funcs"), nil
+	}
+
+	pkgs, deps, err := loadProject(ctx, project)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
+	}
+	opts.Dependencies = deps
+	target, err := findTarget(pkgs, project, file, funcName, line)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to find target: " + err.Error()), nil
+	}
+	if opts.IncludeEdges {
+		graph, err := tracer.ExtractCallGraph(target, int(depth), pkgs, opts)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to extract call graph: " + err.Error()), nil
+		}
+		return mcp.NewToolResultStructured(graph, "called_funcs"), nil
+	}
+	funcs, err := tracer.ExtractCalledFuncs(target, int(depth), pkgs, opts)
+	if err != nil && !errors.Is(err, tracer.ErrTruncated) {
+		return mcp.NewToolResultError("Failed to extract called functions: " + err.Error()), nil
+	}
+
+	return structuredResult(funcs, "called_funcs", err), nil
+}
+
+// findCallersHandler handles requests for the 'find_callers' tool.
+func findCallersHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := request.RequireString("project")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	file := request.GetString("file", "")
 	funcName, err := request.RequireString("func")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	line := request.GetInt("line", 0)
 	// Use 0 as default if depth is not provided
 	depth, err := request.RequireInt("depth")
 	if err != nil {
-		depth = 3
+		depth = 0
 	}
 
-	pkgs, err := loadProject(project)
+	opts, err := optionsFromRequest(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if request.GetBool("use_index", false) {
+		if err := checkIndexOptions(opts); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		idx, fn, err := indexTarget(ctx, project, file, funcName, line)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to query index: " + err.Error()), nil
+		}
+		return mcp.NewToolResultStructured(idx.Callers(fn.Name, int(depth)), "find_callers"), nil
+	}
+
+	pkgs, deps, err := loadProject(ctx, project)
 	if err != nil {
 		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
 	}
-	target, err := tracer.FindTarget(pkgs, file, funcName)
+	opts.Dependencies = deps
+	target, err := findTarget(pkgs, project, file, funcName, line)
 	if err != nil {
 		return mcp.NewToolResultError("Failed to find target: " + err.Error()), nil
 	}
-	funcs, err := tracer.ExtractCalledFuncs(target, int(depth), pkgs)
+	callers, err := tracer.FindCallers(target, int(depth), pkgs, opts)
+	if err != nil && !errors.Is(err, tracer.ErrTruncated) {
+		return mcp.NewToolResultError("Failed to find callers: " + err.Error()), nil
+	}
+
+	return structuredResult(callers, "find_callers", err), nil
+}
+
+// callGraphHandler handles requests for the 'call_graph' tool.
+func callGraphHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := request.RequireString("project")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	file := request.GetString("file", "")
+	funcName, err := request.RequireString("func")
 	if err != nil {
-		return mcp.NewToolResultError("Failed to extract called functions: " + err.Error()), nil
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	line := request.GetInt("line", 0)
+	// Use 1 as default if depth is not provided
+	depth, err := request.RequireInt("depth")
+	if err != nil {
+		depth = 1
+	}
+
+	opts, err := optionsFromRequest(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts.Format = request.GetString("format", tracer.FormatMermaid)
+	if opts.Format != tracer.FormatDOT && opts.Format != tracer.FormatMermaid {
+		return mcp.NewToolResultError("Unsupported graph format: " + opts.Format), nil
+	}
+
+	pkgs, deps, err := loadProject(ctx, project)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
+	}
+	opts.Dependencies = deps
+	target, err := findTarget(pkgs, project, file, funcName, line)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to find target: " + err.Error()), nil
+	}
+	graph, err := tracer.RenderCallGraph(target, int(depth), pkgs, opts)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to render call graph: " + err.Error()), nil
 	}
 
-	return mcp.NewToolResultStructured(funcs, "called_funcs"), nil
+	return mcp.NewToolResultText(graph), nil
+}
+
+// refGlobalsHandler handles requests for the 'ref_globals' tool.
+func refGlobalsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := request.RequireString("project")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	file := request.GetString("file", "")
+	funcName, err := request.RequireString("func")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	line := request.GetInt("line", 0)
+	// Use 0 as default if depth is not provided
+	depth, err := request.RequireInt("depth")
+	if err != nil {
+		depth = 0
+	}
+
+	opts, err := optionsFromRequest(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	pkgs, deps, err := loadProject(ctx, project)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
+	}
+	opts.Dependencies = deps
+	target, err := findTarget(pkgs, project, file, funcName, line)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to find target: " + err.Error()), nil
+	}
+	globals, err := tracer.ExtractGlobals(target, int(depth), pkgs, opts)
+	if err != nil && !errors.Is(err, tracer.ErrTruncated) {
+		return mcp.NewToolResultError("Failed to extract globals: " + err.Error()), nil
+	}
+
+	return structuredResult(globals, "ref_globals", err), nil
+}
+
+// implementationsHandler handles requests for the 'implementations' tool.
+func implementationsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := request.RequireString("project")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	typeName, err := request.RequireString("type")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	withSource := request.GetBool("include_source", false)
+
+	pkgs, _, err := loadProject(ctx, project)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
+	}
+	typeObj, err := tracer.FindType(pkgs, typeName)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to find type: " + err.Error()), nil
+	}
+
+	var related []tracer.Implementation
+	if types.IsInterface(typeObj.Type()) {
+		related, err = tracer.FindImplementations(typeObj, pkgs, withSource)
+	} else {
+		related, err = tracer.FindInterfaces(typeObj, pkgs, withSource)
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to find implementations: " + err.Error()), nil
+	}
+
+	return mcp.NewToolResultStructured(related, "implementations"), nil
 }
 
 // getSnippetHandler handles requests for the 'get_snippet' tool; it reuses funcCodeHandler.
 var getSnippetHandler = funcCodeHandler
 
+// callPathHandler handles requests for the 'call_path' tool.
+func callPathHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := request.RequireString("project")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	fromFile := request.GetString("file", "")
+	fromFunc, err := request.RequireString("func")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	fromLine := request.GetInt("line", 0)
+	toFile := request.GetString("to_file", "")
+	toFunc, err := request.RequireString("to_func")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	toLine := request.GetInt("to_line", 0)
+	k := request.GetInt("k", 1)
+	maxHops := request.GetInt("max_hops", 10)
+
+	opts, err := optionsFromRequest(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	pkgs, deps, err := loadProject(ctx, project)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
+	}
+	opts.Dependencies = deps
+	from, err := findTarget(pkgs, project, fromFile, fromFunc, fromLine)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to find source function: " + err.Error()), nil
+	}
+	to, err := findTarget(pkgs, project, toFile, toFunc, toLine)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to find destination function: " + err.Error()), nil
+	}
+	paths, err := tracer.FindCallPaths(from, to, k, maxHops, pkgs, opts)
+	if err != nil && !errors.Is(err, tracer.ErrTruncated) {
+		return mcp.NewToolResultError("Failed to find call paths: " + err.Error()), nil
+	}
+
+	return structuredResult(paths, "call_path", err), nil
+}
+
+// dependencySurfaceHandler handles requests for the 'dependency_surface' tool.
+func dependencySurfaceHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := request.RequireString("project")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	file := request.GetString("file", "")
+	funcName, err := request.RequireString("func")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	line := request.GetInt("line", 0)
+	// Use 3 as default if depth is not provided
+	depth, err := request.RequireInt("depth")
+	if err != nil {
+		depth = 3
+	}
+
+	opts, err := optionsFromRequest(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	pkgs, deps, err := loadProject(ctx, project)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
+	}
+	opts.Dependencies = deps
+	target, err := findTarget(pkgs, project, file, funcName, line)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to find target: " + err.Error()), nil
+	}
+	surface, err := tracer.BuildDependencySurface(target, int(depth), pkgs, opts)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to build dependency surface: " + err.Error()), nil
+	}
+
+	return mcp.NewToolResultStructured(surface, "dependency_surface"), nil
+}
+
+// impactAnalysisHandler handles requests for the 'impact_analysis' tool.
+func impactAnalysisHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := request.RequireString("project")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	base := request.GetString("base", "main")
+	roots, err := tracer.ParseRootKinds(request.GetString("roots", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	opts, err := optionsFromRequest(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	changes, err := tracer.ChangedLines(ctx, project, base)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to read changes: " + err.Error()), nil
+	}
+	pkgs, deps, err := loadProject(ctx, project)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to load project: " + err.Error()), nil
+	}
+	opts.Dependencies = deps
+	impact, err := tracer.AnalyzeImpact(base, changes, roots, pkgs, opts)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to analyze impact: " + err.Error()), nil
+	}
+
+	return mcp.NewToolResultStructured(impact, "impact_analysis"), nil
+}
+
+// withLineParam adds the 'line' parameter that targets a function literal
+// inside 'func'.
+func withLineParam() mcp.ToolOption {
+	return mcp.WithNumber("line", mcp.Description("Line number of a function literal inside 'func' (e.g. an HTTP handler closure or goroutine body) to target that closure instead of the whole function. Closures are named after their function and position, e.g. 'Handler$29:26'."))
+}
+
+// withExternalParams adds the 'external', 'external_depth', 'external_allow'
+// and 'external_deny' parameters that record and trace calls into dependencies.
+func withExternalParams() mcp.ToolOption {
+	options := []mcp.ToolOption{
+		mcp.WithString("external", mcp.Enum(string(tracer.ExternalLeaf), string(tracer.ExternalTrace)), mcp.Description("Also record calls into the standard library and third-party dependencies: 'leaf' lists each external function with its signature and doc comment, 'trace' also follows their source up to 'external_depth' levels. Omit to stay inside the project. Not available with 'use_index'.")),
+		mcp.WithNumber("external_depth", mcp.Description("Levels of dependency functions to trace into when 'external' is 'trace'. Defaults to 1.")),
+		mcp.WithString("external_allow", mcp.Description("Comma-separated dependency package patterns to follow, e.g. 'net/http,github.com/jackc/pgx/...'. '...' matches any string. Defaults to all packages.")),
+		mcp.WithString("external_deny", mcp.Description("Comma-separated dependency package patterns never to follow, e.g. 'fmt,runtime/...'. Takes precedence over 'external_allow'.")),
+	}
+	return func(tool *mcp.Tool) {
+		for _, option := range options {
+			option(tool)
+		}
+	}
+}
+
+// withUseIndexParam adds the 'use_index' parameter that answers from the
+// persistent project index.
+func withUseIndexParam() mcp.ToolOption {
+	return mcp.WithBoolean("use_index", mcp.Description("Answer from the persistent project index in '<project>/.gct/index.json' instead of type-checking the whole project. The index is built on first use and only changed packages are re-indexed afterwards. The index lists names only and cannot be combined with 'resolve_interfaces', 'type_depth', 'methods' or 'external'. Defaults to false."))
+}
+
 // RegisterTools defines all tools on the server and registers their handlers.
 func RegisterTools(s *server.MCPServer) {
 	// Tool 1: generate a full recursive dependency report.
 	fullReportTool := mcp.NewTool("full_report",
-		mcp.WithDescription("Generate a comprehensive dependency analysis report for a Go function. This tool traces all functions, methods, and types that your target function depends on, recursively exploring the call chain to the specified depth. Perfect for understanding the complete scope and impact of code changes. Note: This generates extensive output and may consume significant tokens. For focused exploration, start with 'ref_types' and 'called_funcs' tools first."),
+		mcp.WithDescription("Generate a comprehensive dependency analysis report for a Go function. This tool traces all functions, methods, and types that your target function depends on, recursively exploring the call chain to the specified depth. Perfect for understanding the complete scope and impact of code changes. Note: This generates extensive output and may consume significant tokens; set 'max_tokens' or 'max_bytes' to cap it. For focused exploration, start with 'ref_types' and 'called_funcs' tools first."),
 		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory (e.g., '/home/user/myproject' or 'C:\\Users\\Dev\\myproject')")),
-		mcp.WithString("file", mcp.Required(), mcp.Description("Path to the Go file containing your target function, relative to project root (e.g., 'internal/handlers/user.go')")),
-		mcp.WithString("func", mcp.Required(), mcp.Description("Exact name of the function or method you want to analyze (e.g., 'ProcessUserData' or 'HandleRequest')")),
+		mcp.WithString("file", mcp.Description("Path to the Go file containing your target function, relative to project root (e.g., 'internal/handlers/user.go'). Optional when 'func' is a full symbol path such as 'myproj/internal/db.(*Store).Save'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Exact name of the function or method you want to analyze (e.g., 'ProcessUserData' or 'HandleRequest'). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
 		mcp.WithNumber("depth", mcp.Required(), mcp.Description("How many levels deep to trace dependencies. Start with 1-2 for initial exploration, use 3-4 for comprehensive analysis. Higher values generate more extensive reports.")),
+		mcp.WithBoolean("resolve_interfaces", mcp.Description("Resolve calls made through interfaces to every concrete project type that implements the interface, and keep tracing into those implementations. Defaults to false.")),
+		mcp.WithBoolean("include_edges", mcp.Description("Add a 'Call Edges' section listing every caller -> callee edge with its call-site position (file:line:col), depth and kind (direct, value, defer, go). Defaults to false.")),
+		mcp.WithString("format", mcp.Enum(tracer.FormatText, tracer.FormatJSON), mcp.Description("Output format: 'text' for the human-readable report (default) or 'json' for a structured report with the target, functions and types (with source, file, line range and package) and call edges.")),
+		mcp.WithNumber("type_depth", mcp.Description("Also include the project types that referenced types depend on: struct fields, embedded types, map/slice/chan element types, method signatures and interface methods. 0 = off (default), 1 = one level (e.g. 'User' adds 'Address'), 2+ = deeper (e.g. 'Address' adds 'GeoPoint').")),
+		mcp.WithString("methods", mcp.Enum(string(tracer.MethodsSignatures), string(tracer.MethodsFull)), mcp.Description("Attach the methods declared on each referenced project type: 'signatures' for signatures only or 'full' for complete method bodies. Omit to leave methods out.")),
+		withExternalParams(),
+		mcp.WithString("order", mcp.Enum(string(tracer.OrderPosition), string(tracer.OrderDiscovery)), mcp.Description("Order of the listed functions, types and variables: 'position' sorts by package path, then file, then position in the file (default); 'discovery' lists them in the breadth-first order the trace reached them.")),
+		mcp.WithNumber("max_bytes", mcp.Description("Keep the report under roughly this many bytes. Sources farthest from the target collapse to signatures first, then are omitted and listed with the 'func_code' arguments to fetch them. The target's source and the summary lists are always kept. 0 = unlimited (default).")),
+		mcp.WithNumber("max_tokens", mcp.Description("Like 'max_bytes', counted in tokens estimated at 4 bytes each. When both are set, the smaller budget applies.")),
+		mcp.WithString("snippets", mcp.Enum(string(tracer.SnippetsSkeleton), string(tracer.SnippetsHybrid)), mcp.Description("Show less source for broad exploration: 'skeleton' shows every function, method and closure as its doc comment and signature, and types with their fields but attached methods as signatures; 'hybrid' shows the target in full and its dependencies as in 'skeleton'. Omit for full sources.")),
+		mcp.WithBoolean("strip_comments", mcp.Description("Leave doc comments, struct field comments and comments inside function bodies out of the snippets for more compact output. Defaults to false, which keeps every comment in place.")),
 	)
 	s.AddTool(fullReportTool, fullReportHandler)
 
@@ -184,8 +638,9 @@ func RegisterTools(s *server.MCPServer) {
 	funcCodeTool := mcp.NewTool("func_code",
 		mcp.WithDescription("Get the complete, formatted source code for any Go function or method. This tool is perfect for examining specific functions you've discovered through 'called_funcs' or 'ref_types' analysis. Use this when you need to see the actual implementation details, understand function logic, or review code before making changes."),
 		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory (same as used in previous analysis)")),
-		mcp.WithString("file", mcp.Required(), mcp.Description("Path to the Go file containing the function, relative to project root (e.g., 'pkg/database/user.go')")),
-		mcp.WithString("func", mcp.Required(), mcp.Description("Exact function or method name to retrieve (case-sensitive, e.g., 'CreateUser' or 'ValidateEmail')")),
+		mcp.WithString("file", mcp.Description("Path to the Go file containing the function, relative to project root (e.g., 'pkg/database/user.go'). Optional when 'func' is a full symbol path such as 'myproj/internal/db.(*Store).Save'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Exact function or method name to retrieve (case-sensitive, e.g., 'CreateUser' or 'ValidateEmail'). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
 	)
 	s.AddTool(funcCodeTool, funcCodeHandler)
 
@@ -193,9 +648,15 @@ func RegisterTools(s *server.MCPServer) {
 	refTypesTool := mcp.NewTool("ref_types",
 		mcp.WithDescription("Discover all custom data types (structs, interfaces, type aliases) used by a function and its dependencies. This is your primary tool for understanding data structures and type relationships in Go code. Perfect for mapping out the data flow and identifying important types before diving into implementation details. Recommended workflow: Start with depth 1-2 for quick type overview, then use depth 3 for comprehensive type analysis."),
 		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory")),
-		mcp.WithString("file", mcp.Required(), mcp.Description("Path to Go file containing your target function, relative to project root")),
-		mcp.WithString("func", mcp.Required(), mcp.Description("Function name to analyze for type references (exact name, case-sensitive)")),
+		mcp.WithString("file", mcp.Description("Path to Go file containing your target function, relative to project root. Optional when 'func' is a full symbol path such as 'myproj/internal/db.(*Store).Save'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Function name to analyze for type references (exact name, case-sensitive). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
 		mcp.WithNumber("depth", mcp.Required(), mcp.Description("Analysis depth: 1 = direct types only, 2 = types used by called functions, 3 = comprehensive type analysis. Start with 1-2 for exploration.")),
+		mcp.WithBoolean("resolve_interfaces", mcp.Description("Resolve calls made through interfaces to every concrete project type that implements the interface, and keep tracing into those implementations. Defaults to false.")),
+		withUseIndexParam(),
+		mcp.WithNumber("type_depth", mcp.Description("Also include the project types that referenced types depend on: struct fields, embedded types, map/slice/chan element types, method signatures and interface methods. 0 = off (default), 1 = one level (e.g. 'User' adds 'Address'), 2+ = deeper (e.g. 'Address' adds 'GeoPoint').")),
+		mcp.WithString("methods", mcp.Enum(string(tracer.MethodsSignatures), string(tracer.MethodsFull)), mcp.Description("Attach the methods declared on each referenced project type: 'signatures' for signatures only or 'full' for complete method bodies. Omit to leave methods out. When set, each type is returned with its source and methods instead of as a plain name.")),
+		mcp.WithString("order", mcp.Enum(string(tracer.OrderPosition), string(tracer.OrderDiscovery)), mcp.Description("Order of the listed types: 'position' sorts by package path, then file, then position in the file (default); 'discovery' lists them in the breadth-first order the trace reached them.")),
 	)
 	s.AddTool(refTypesTool, refTypesHandler)
 
@@ -203,9 +664,15 @@ func RegisterTools(s *server.MCPServer) {
 	calledFuncsTool := mcp.NewTool("called_funcs",
 		mcp.WithDescription("Trace the call chain of a Go function to understand what other functions and methods it depends on. This is your primary tool for mapping execution flow and identifying critical dependencies. Use this to understand the scope of changes, find potential side effects, or trace through complex business logic. Recommended approach: Start with depth 1 to see immediate dependencies, then increase to depth 2-3 to trace the full call chain."),
 		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory")),
-		mcp.WithString("file", mcp.Required(), mcp.Description("Path to Go file containing your target function, relative to project root")),
-		mcp.WithString("func", mcp.Required(), mcp.Description("Function name to trace calls from (exact name, case-sensitive)")),
+		mcp.WithString("file", mcp.Description("Path to Go file containing your target function, relative to project root. Optional when 'func' is a full symbol path such as 'myproj/internal/db.(*Store).Save'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Function name to trace calls from (exact name, case-sensitive). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
 		mcp.WithNumber("depth", mcp.Required(), mcp.Description("Call tracing depth: 1 = immediate calls only, 2 = calls and their calls, 3 = comprehensive call chain. Most useful at depth 1-2.")),
+		mcp.WithBoolean("resolve_interfaces", mcp.Description("Resolve calls made through interfaces to every concrete project type that implements the interface, and keep tracing into those implementations. Defaults to false.")),
+		mcp.WithBoolean("include_edges", mcp.Description("Return the call graph instead of a flat list: every caller -> callee edge with its call-site position (file:line:col), depth and kind (direct, value, defer, go). Use this to see the path to each dependency. Defaults to false.")),
+		withUseIndexParam(),
+		withExternalParams(),
+		mcp.WithString("order", mcp.Enum(string(tracer.OrderPosition), string(tracer.OrderDiscovery)), mcp.Description("Order of the listed functions: 'position' sorts by package path, then file, then position in the file (default); 'discovery' lists them in the breadth-first order the trace reached them.")),
 	)
 	s.AddTool(calledFuncsTool, calledFuncsHandler)
 
@@ -213,8 +680,100 @@ func RegisterTools(s *server.MCPServer) {
 	getSnippetTool := mcp.NewTool("get_snippet",
 		mcp.WithDescription("Get a clean, formatted code snippet for any function or method you've discovered during analysis. This is identical to 'func_code' and perfect for quickly inspecting specific functions found through 'called_funcs' or 'ref_types' exploration. Use this when you want to see implementation details without generating a full dependency report."),
 		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory")),
-		mcp.WithString("file", mcp.Required(), mcp.Description("Path to Go file containing the function, relative to project root")),
-		mcp.WithString("func", mcp.Required(), mcp.Description("Exact function or method name to retrieve (case-sensitive)")),
+		mcp.WithString("file", mcp.Description("Path to Go file containing the function, relative to project root. Optional when 'func' is a full symbol path such as 'myproj/internal/db.(*Store).Save'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Exact function or method name to retrieve (case-sensitive). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
 	)
 	s.AddTool(getSnippetTool, getSnippetHandler)
+
+	// Tool 6: list all functions and methods that call a function.
+	findCallersTool := mcp.NewTool("find_callers",
+		mcp.WithDescription("Trace a Go function in reverse to find every function and method in the project that calls it. This is your primary tool for answering \"who breaks if I change this?\". Use it before changing a function's signature or behavior to see every call site that depends on it. Recommended approach: Start with depth 0 to see direct callers, then increase to depth 1-2 to walk further up toward entry points."),
+		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory")),
+		mcp.WithString("file", mcp.Description("Path to Go file containing your target function, relative to project root. Optional when 'func' is a full symbol path such as 'myproj/internal/db.(*Store).Save'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Function name to find callers of (exact name, case-sensitive). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
+		mcp.WithNumber("depth", mcp.Description("Caller tracing depth: 0 = direct callers only, 1 = callers and their callers, 2+ = walk further up the call chain. Defaults to 0.")),
+		mcp.WithBoolean("resolve_interfaces", mcp.Description("Resolve calls made through interfaces to every concrete project type that implements the interface, and keep tracing into those implementations. Defaults to false.")),
+		withUseIndexParam(),
+	)
+	s.AddTool(findCallersTool, findCallersHandler)
+
+	// Tool 7: render the call graph of a function as a diagram.
+	callGraphTool := mcp.NewTool("call_graph",
+		mcp.WithDescription("Render the call chain of a Go function as a Graphviz DOT or Mermaid flowchart diagram, ready to paste into design docs and PR descriptions. Functions are grouped by package and methods are labelled with their receiver, e.g. '(*Store).Save'. Dashed arrows are functions referenced as values; 'defer' and 'go' arrows are labelled. Recommended approach: Use depth 1-2 to keep diagrams readable."),
+		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory")),
+		mcp.WithString("file", mcp.Description("Path to Go file containing your target function, relative to project root. Optional when 'func' is a full symbol path such as 'myproj/internal/db.(*Store).Save'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Function name to draw the call graph from (exact name, case-sensitive). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
+		mcp.WithNumber("depth", mcp.Description("Call tracing depth: 0 = immediate calls only, 1 = calls and their calls, 2+ = deeper call chain. Defaults to 1.")),
+		mcp.WithString("format", mcp.Enum(tracer.FormatMermaid, tracer.FormatDOT), mcp.Description("Diagram format: 'mermaid' for a Mermaid flowchart (default) or 'dot' for Graphviz.")),
+		mcp.WithBoolean("resolve_interfaces", mcp.Description("Resolve calls made through interfaces to every concrete project type that implements the interface, and keep tracing into those implementations. Defaults to false.")),
+		withExternalParams(),
+		mcp.WithString("order", mcp.Enum(string(tracer.OrderPosition), string(tracer.OrderDiscovery)), mcp.Description("Order of the listed nodes: 'position' sorts by package path, then file, then position in the file (default); 'discovery' lists them in the breadth-first order the trace reached them.")),
+	)
+	s.AddTool(callGraphTool, callGraphHandler)
+
+	// Tool 8: get all package-level variables and constants referenced by a function.
+	refGlobalsTool := mcp.NewTool("ref_globals",
+		mcp.WithDescription("List the package-level variables and constants a Go function relies on, such as shared clients, configuration constants and sentinel errors (e.g. 'ErrNotFound'). Use this alongside 'ref_types' to understand the state a function reads or writes. Declarations can be inspected in 'full_report', which includes their source."),
+		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory")),
+		mcp.WithString("file", mcp.Description("Path to Go file containing your target function, relative to project root. Optional when 'func' is a full symbol path such as 'myproj/internal/db.(*Store).Save'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Function name to analyze for global references (exact name, case-sensitive). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
+		mcp.WithNumber("depth", mcp.Description("Analysis depth: 0 = globals used directly by the function, 1 = also those used by the functions it calls, 2+ = deeper. Defaults to 0.")),
+		mcp.WithBoolean("resolve_interfaces", mcp.Description("Resolve calls made through interfaces to every concrete project type that implements the interface, and keep tracing into those implementations. Defaults to false.")),
+		mcp.WithString("order", mcp.Enum(string(tracer.OrderPosition), string(tracer.OrderDiscovery)), mcp.Description("Order of the listed variables and constants: 'position' sorts by package path, then file, then position in the file (default); 'discovery' lists them in the breadth-first order the trace reached them.")),
+	)
+	s.AddTool(refGlobalsTool, refGlobalsHandler)
+
+	// Tool 9: find the implementations of an interface, or the interfaces a type implements.
+	implementationsTool := mcp.NewTool("implementations",
+		mcp.WithDescription("Answer \"which types implement this?\" for Go interfaces. Given an interface declared in the project, list every project type that satisfies it, through a value or a pointer receiver ('via_pointer' marks types where only the pointer does). Generic types are listed when they satisfy it for every type argument. Given a concrete type, list the project interfaces it implements instead. Use this when a trace stops at an interface method."),
+		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory")),
+		mcp.WithString("type", mcp.Required(), mcp.Description("Interface or concrete type name, optionally qualified by package name or path (e.g. 'Store', 'db.Store' or 'myproj/internal/db.Store')")),
+		mcp.WithBoolean("include_source", mcp.Description("Include the type declaration source for every result. Defaults to false.")),
+	)
+	s.AddTool(implementationsTool, implementationsHandler)
+
+	// Tool 10: find the shortest call chains between two functions.
+	callPathTool := mcp.NewTool("call_path",
+		mcp.WithDescription("Answer \"how does execution get from A to B?\" by finding the shortest call chains from one Go function to another. Each hop lists the caller, the callee, the call kind and the exact call-site location, so you can follow the chain through the source. Use this instead of tracing full reports by hand when you already know both ends. Recommended approach: Start with k=1; raise 'k' to see alternative routes and 'max_hops' when no path is found."),
+		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory")),
+		mcp.WithString("file", mcp.Description("Path to Go file containing the source function, relative to project root. Optional when 'func' is a full symbol path such as 'myproj/cmd/app.main'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Source function name where the paths start (exact name, case-sensitive). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
+		mcp.WithString("to_file", mcp.Description("Path to Go file containing the destination function, relative to project root. Optional when 'to_func' is a full symbol path")),
+		mcp.WithString("to_func", mcp.Required(), mcp.Description("Destination function name where the paths end (exact name, case-sensitive). Qualify methods by receiver type when names clash")),
+		mcp.WithNumber("to_line", mcp.Description("Line number of a function literal inside 'to_func' to make that closure the destination.")),
+		mcp.WithNumber("k", mcp.Description("Number of shortest paths to return, shortest first. Defaults to 1.")),
+		mcp.WithNumber("max_hops", mcp.Description("Maximum number of calls in a path. Defaults to 10.")),
+		mcp.WithBoolean("resolve_interfaces", mcp.Description("Resolve calls made through interfaces to every concrete project type that implements the interface, so paths can pass through those implementations. Defaults to false.")),
+	)
+	s.AddTool(callPathTool, callPathHandler)
+
+	// Tool 11: summarise the standard library and third-party APIs a function relies on.
+	dependencySurfaceTool := mcp.NewTool("dependency_surface",
+		mcp.WithDescription("Summarise every standard library and third-party symbol a Go function relies on, directly or through the project functions it calls, grouped by module and version as recorded in go.mod. Functions, methods and types are listed per package. Use this for upgrade risk assessment, e.g. \"which of our flows touch aws-sdk-go S3 APIs?\", and narrow the answer with 'external_allow'."),
+		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory")),
+		mcp.WithString("file", mcp.Description("Path to Go file containing your target function, relative to project root. Optional when 'func' is a full symbol path such as 'myproj/internal/db.(*Store).Save'")),
+		mcp.WithString("func", mcp.Required(), mcp.Description("Function name to summarise the dependencies of (exact name, case-sensitive). Qualify methods by receiver type when names clash, e.g. 'UserRepo.Get' or '(*UserRepo).Get'")),
+		withLineParam(),
+		mcp.WithNumber("depth", mcp.Description("Project call depth to follow: 0 = the function itself only, 1 = also the project functions it calls, 2+ = deeper. Defaults to 3.")),
+		// Worded apart from withExternalParams on purpose: the surface never
+		// follows dependency code, so these patterns only filter the report.
+		mcp.WithString("external_allow", mcp.Description("Comma-separated dependency package patterns to report, e.g. 'github.com/aws/aws-sdk-go/service/s3/...'. '...' matches any string. Defaults to all packages.")),
+		mcp.WithString("external_deny", mcp.Description("Comma-separated dependency package patterns to leave out, e.g. 'fmt,errors'. Takes precedence over 'external_allow'.")),
+	)
+	s.AddTool(dependencySurfaceTool, dependencySurfaceHandler)
+
+	// Tool 12: find the entry points affected by the changes on a branch.
+	impactAnalysisTool := mcp.NewTool("impact_analysis",
+		mcp.WithDescription("Answer \"what does this branch affect?\" for code review. Runs git diff against the merge base of 'base' and HEAD (uncommitted changes and untracked files included), maps every changed line to the Go function enclosing it or to the package-level variable, constant or type declared on it and the functions using that declaration, and traces callers in reverse to list every affected function and every entry point (main functions, exported API, HTTP handlers) whose call tree reaches changed code."),
+		mcp.WithString("project", mcp.Required(), mcp.Description("Absolute path to your Go project root directory, inside a git repository")),
+		mcp.WithString("base", mcp.Description("Branch or revision to compare against, e.g. 'main' or 'origin/release-1.2'. Defaults to 'main'.")),
+		mcp.WithString("roots", mcp.Description("Comma-separated entry point kinds to report: 'main' for main functions, 'exported' for exported functions and methods outside package main, 'http' for functions and closures with an http.HandlerFunc signature. Defaults to all three.")),
+		mcp.WithBoolean("resolve_interfaces", mcp.Description("Trace callers through interface method calls, so callers of an interface method count as callers of every implementation. Defaults to false.")),
+	)
+	s.AddTool(impactAnalysisTool, impactAnalysisHandler)
 }
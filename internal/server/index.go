@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+	"sync"
+
+	"go-call-tracer/internal/tracer"
+)
+
+// indexMu serialises refreshes of the on-disk project indexes.
+var indexMu sync.Mutex
+
+// loadIndex opens the on-disk index of a project, re-indexes the packages whose
+// files changed and saves it back if anything was updated. Cancelling ctx stops
+// the refresh.
+func loadIndex(ctx context.Context, projectPath string) (*tracer.Index, error) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	dir, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	path := tracer.DefaultIndexPath(dir)
+	idx, err := tracer.LoadIndex(path, dir)
+	if err != nil {
+		return nil, err
+	}
+	reportProgress(ctx, "Refreshing index of %s", dir)
+	updated, err := idx.Update(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(updated) == 0 {
+		log.Printf("Index up to date for project: %s", dir)
+		return idx, nil
+	}
+	log.Printf("Index refreshed %d package(s) for project: %s", len(updated), dir)
+	if err := idx.Save(path); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// indexTarget opens the project index and locates the requested function in it,
+// or the function literal starting on line inside it if line is non-zero.
+func indexTarget(ctx context.Context, project, file, funcName string, line int) (*tracer.Index, *tracer.IndexedFunc, error) {
+	idx, err := loadIndex(ctx, project)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file != "" {
+		file = tracer.ResolveFile(project, file)
+	}
+	fn, err := idx.FindFunc(file, funcName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if line != 0 {
+		fn, err = idx.FindClosure(fn, line)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return idx, fn, nil
+}
+
+// checkIndexOptions rejects the options that the project index cannot honour:
+// it records neither interface implementations, type dependencies, method
+// sources nor calls into dependencies.
+func checkIndexOptions(opts tracer.Options) error {
+	switch {
+	case opts.ResolveInterfaces:
+		return errors.New("'resolve_interfaces' is not available with 'use_index'")
+	case opts.TypeDepth > 0:
+		return errors.New("'type_depth' is not available with 'use_index'")
+	case opts.Methods != tracer.MethodsNone:
+		return errors.New("'methods' is not available with 'use_index'")
+	case opts.External != tracer.ExternalNone:
+		return errors.New("'external' is not available with 'use_index'")
+	}
+	return nil
+}
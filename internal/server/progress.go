@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// progressKey is the context key of the reporter for the current tool call.
+type progressKey struct{}
+
+// progressReporter sends MCP progress notifications for one tool call whose
+// client supplied a progress token.
+type progressReporter struct {
+	ctx    context.Context
+	server *server.MCPServer
+	token  mcp.ProgressToken
+
+	mu       sync.Mutex
+	progress float64
+}
+
+// ProgressMiddleware gives every tool call that carries a progress token a
+// reporter, so that loading and analysis report their progress to the client.
+func ProgressMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+			if s := server.ServerFromContext(ctx); s != nil {
+				reporter := &progressReporter{ctx: ctx, server: s, token: request.Params.Meta.ProgressToken}
+				ctx = context.WithValue(ctx, progressKey{}, reporter)
+			}
+		}
+		return next(ctx, request)
+	}
+}
+
+// send notifies the client of a progress message. The progress value counts
+// the messages sent, as the total amount of work is not known in advance.
+func (p *progressReporter) send(message string) {
+	p.mu.Lock()
+	p.progress++
+	progress := p.progress
+	p.mu.Unlock()
+
+	err := p.server.SendNotificationToClient(p.ctx, "notifications/progress", map[string]any{
+		"progressToken": p.token,
+		"progress":      progress,
+		"message":       message,
+	})
+	if err != nil {
+		log.Printf("Failed to send progress notification: %v", err)
+	}
+}
+
+// progressFunc returns the function reporting progress for the tool call of
+// ctx, or nil if its client asked for none.
+func progressFunc(ctx context.Context) func(message string) {
+	if reporter, ok := ctx.Value(progressKey{}).(*progressReporter); ok {
+		return reporter.send
+	}
+	return nil
+}
+
+// reportProgress reports a progress message for the tool call of ctx, if its
+// client asked for progress.
+func reportProgress(ctx context.Context, format string, args ...any) {
+	if send := progressFunc(ctx); send != nil {
+		send(fmt.Sprintf(format, args...))
+	}
+}
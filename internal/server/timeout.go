@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Timeouts limits how long a tool call may run. When a limit is reached the
+// analysis stops and the tool returns what it found so far, marked as truncated.
+type Timeouts struct {
+	Default time.Duration            // Limit for tools without their own; 0 means no limit.
+	PerTool map[string]time.Duration // Limits by tool name; 0 lifts the default for that tool.
+}
+
+// ParseToolTimeouts parses a comma-separated list of tool=duration pairs,
+// e.g. "full_report=2m,call_path=30s".
+func ParseToolTimeouts(list string) (map[string]time.Duration, error) {
+	limits := make(map[string]time.Duration)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tool, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(tool) == "" {
+			return nil, fmt.Errorf("invalid tool timeout '%s'; expected tool=duration", entry)
+		}
+		limit, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid duration in tool timeout '%s'", entry)
+		}
+		limits[strings.TrimSpace(tool)] = limit
+	}
+	return limits, nil
+}
+
+// TimeoutMiddleware applies the limit for each tool to the context its handler
+// receives.
+func TimeoutMiddleware(timeouts Timeouts) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			limit, ok := timeouts.PerTool[request.Params.Name]
+			if !ok {
+				limit = timeouts.Default
+			}
+			if limit <= 0 {
+				return next(ctx, request)
+			}
+			ctx, cancel := context.WithTimeout(ctx, limit)
+			defer cancel()
+			return next(ctx, request)
+		}
+	}
+}
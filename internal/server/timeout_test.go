@@ -0,0 +1,34 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseToolTimeouts(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{"", map[string]time.Duration{}, false},
+		{"full_report=2m", map[string]time.Duration{"full_report": 2 * time.Minute}, false},
+		{" full_report = 2m , call_path=30s,", map[string]time.Duration{"full_report": 2 * time.Minute, "call_path": 30 * time.Second}, false},
+		{"find_callers=0", map[string]time.Duration{"find_callers": 0}, false},
+		{"full_report", nil, true},
+		{"=2m", nil, true},
+		{"full_report=soon", nil, true},
+		{"full_report=-1s", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseToolTimeouts(tt.list)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseToolTimeouts(%q) error = %v, want error %v", tt.list, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseToolTimeouts(%q) = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
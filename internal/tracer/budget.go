@@ -0,0 +1,212 @@
+// internal/tracer/budget.go
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Levels a symbol's source is reduced to when a report exceeds its size budget.
+const (
+	TrimSignature = "signature" // Only the declaration and doc comment are kept.
+	TrimOmitted   = "omitted"   // The source is left out.
+)
+
+// bytesPerToken is the rough number of bytes per token used to turn
+// Options.MaxTokens into a byte budget.
+const bytesPerToken = 4
+
+// sizeBudget returns the byte budget set by opts, or 0 if there is none.
+func (opts Options) sizeBudget() int {
+	budget := opts.MaxBytes
+	if tokens := opts.MaxTokens * bytesPerToken; tokens > 0 && (budget <= 0 || tokens < budget) {
+		budget = tokens
+	}
+	return budget
+}
+
+// FitReport trims the dependency sources of a report until it fits the budget
+// set by opts.MaxBytes and opts.MaxTokens when rendered in opts.Format.
+// Symbols farthest from the target give way first: function bodies collapse
+// to their signatures, then whole sources are omitted. The target's own source
+// and the summary lists are always kept, so a very small budget can still be
+// exceeded. Diagram formats carry no sources and are left alone.
+func FitReport(report *Report, opts Options) error {
+	budget := opts.sizeBudget()
+	if budget <= 0 || opts.Format == FormatDOT || opts.Format == FormatMermaid {
+		return nil
+	}
+	rendered, err := RenderReport(report, opts)
+	if err != nil {
+		return err
+	}
+	size := len(rendered)
+	if size <= budget {
+		return nil
+	}
+
+	opts.progress("Trimming report of %d bytes to fit %d bytes", size, budget)
+	report.Notice = fmt.Sprintf("Sources were trimmed to fit the report into about %d bytes; farther dependencies were reduced first. "+
+		"Fetch a trimmed project function with the func_code tool (CLI: gct-cli -t) by its package path, receiver and name, "+
+		"e.g. 'myproj/internal/db.(*Store).Save', adding 'line' for a function literal, or rerun with a larger budget or a lower depth.", budget)
+	size += len(report.Notice) + len("\n--- Omitted Snippets ---\n")
+
+	// Candidates are ranked farthest first; at equal depth, the symbol listed
+	// last gives way first.
+	var candidates []*Symbol
+	for _, list := range [][]Symbol{report.Functions, report.Types, report.Globals, report.External} {
+		for i := range list {
+			candidates = append(candidates, &list[i])
+		}
+	}
+	for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Depth > candidates[j].Depth })
+
+	for _, reduce := range []func(*Symbol){collapseSymbol, omitSymbol} {
+		for _, sym := range candidates {
+			if size <= budget {
+				return nil
+			}
+			before, trimmed := *sym, *sym
+			trimmed.Methods = append([]Symbol(nil), sym.Methods...)
+			reduce(&trimmed)
+			// Keep the change only if the symbol renders smaller for it.
+			if saved := symbolSize(before, opts) - symbolSize(trimmed, opts); saved > 0 {
+				*sym = trimmed
+				size -= saved
+			}
+		}
+	}
+	return nil
+}
+
+// symbolSize returns how many bytes a dependency adds to the report rendered
+// in opts.Format: its snippet, or the line listing it as omitted.
+func symbolSize(sym Symbol, opts Options) int {
+	if opts.Format == FormatJSON {
+		data, _ := json.MarshalIndent(sym, "    ", "  ")
+		return len(data)
+	}
+	if sym.Trimmed == TrimOmitted {
+		return len(omittedLine(sym, true))
+	}
+	var out strings.Builder
+	writeSnippets(&out, []Symbol{sym}, true)
+	return out.Len()
+}
+
+// collapseSymbol reduces a function, or the methods attached to a type, to
+// signatures.
+func collapseSymbol(sym *Symbol) {
+	if sym.Trimmed != "" {
+		return
+	}
+	if signature, ok := signatureOf(sym.Source); ok {
+		sym.Source = signature
+		sym.Trimmed = TrimSignature
+	}
+	for i := range sym.Methods {
+		if signature, ok := signatureOf(sym.Methods[i].Source); ok {
+			sym.Methods[i].Source = signature
+			sym.Methods[i].Trimmed = TrimSignature
+		}
+	}
+}
+
+// omitSymbol drops the source of a symbol and its methods.
+func omitSymbol(sym *Symbol) {
+	if sym.Trimmed == TrimOmitted || sym.Source == "" {
+		return
+	}
+	sym.Source = ""
+	sym.Methods = nil
+	sym.Trimmed = TrimOmitted
+}
+
+// signatureOf reduces the source of a function declaration to its signature
+// and doc comment, and that of a function literal to its type. It reports
+// false for sources that are neither.
+func signatureOf(source string) (string, bool) {
+	if source == "" {
+		return "", false
+	}
+	fset := token.NewFileSet()
+	var node any
+	if file, err := parser.ParseFile(fset, "", "package p\n"+source, parser.ParseComments); err == nil && len(file.Decls) == 1 {
+		fn, ok := file.Decls[0].(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			return "", false
+		}
+		// Printed on its own, a declaration keeps its doc comment but none of
+		// the comments inside its body.
+		fn.Body = nil
+		node = fn
+	} else if expr, err := parser.ParseExprFrom(fset, "", source, 0); err == nil {
+		lit, ok := expr.(*ast.FuncLit)
+		if !ok {
+			return "", false
+		}
+		node = lit.Type
+	} else {
+		return "", false
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+// trimmedNote formats the line that marks a snippet reduced to its signature.
+func trimmedNote(sym Symbol, fetchable bool) string {
+	if !fetchable {
+		return "// Body omitted to fit the size budget.\n"
+	}
+	return "// Body omitted to fit the size budget; func_code: " + fetchSelector(sym) + "\n"
+}
+
+// omittedLine formats the entry listing an omitted symbol. Project functions
+// are listed with a selector func_code accepts.
+func omittedLine(sym Symbol, fetchable bool) string {
+	line := "- " + sym.Name
+	if sym.File != "" {
+		line += fmt.Sprintf(" (%s:%d)", sym.File, sym.StartLine)
+	}
+	if fetchable {
+		line += " [func_code: " + fetchSelector(sym) + "]"
+	}
+	return line + "\n"
+}
+
+// fetchSelector turns the full name of a project function, method or function
+// literal into the arguments func_code takes to fetch it, e.g.
+// "(*myproj/internal/svc.Service).Handler$29:26" becomes
+// "myproj/internal/svc.(*Service).Handler line=29".
+func fetchSelector(sym Symbol) string {
+	name, closure, _ := strings.Cut(sym.Name, "$")
+	selector := sym.Package + "." + name[strings.LastIndex(name, ".")+1:]
+	if recv, method, ok := strings.Cut(strings.TrimPrefix(name, "("), ")."); ok && strings.HasPrefix(name, "(") {
+		pointer := strings.HasPrefix(recv, "*")
+		if bracket := strings.Index(recv, "["); bracket >= 0 {
+			recv = recv[:bracket]
+		}
+		recv = recv[strings.LastIndex(recv, ".")+1:]
+		if pointer {
+			recv = "*" + recv
+		}
+		selector = fmt.Sprintf("%s.(%s).%s", sym.Package, recv, method)
+	}
+	if line, _, ok := strings.Cut(closure, ":"); ok {
+		selector += " line=" + line
+	}
+	return selector
+}
@@ -0,0 +1,184 @@
+package tracer
+
+import (
+	"go/types"
+	"maps"
+	"regexp"
+	"testing"
+)
+
+func TestFetchSelector(t *testing.T) {
+	tests := []struct {
+		sym  Symbol
+		want string
+	}{
+		{Symbol{Name: "myproj/internal/svc.Process", Package: "myproj/internal/svc"}, "myproj/internal/svc.Process"},
+		{Symbol{Name: "(*myproj/internal/svc.Service).Handler", Package: "myproj/internal/svc"}, "myproj/internal/svc.(*Service).Handler"},
+		{Symbol{Name: "(myproj/internal/db.Store).Get", Package: "myproj/internal/db"}, "myproj/internal/db.(Store).Get"},
+		{Symbol{Name: "(*myproj/internal/db.Box[T]).Double", Package: "myproj/internal/db"}, "myproj/internal/db.(*Box).Double"},
+		{Symbol{Name: "(*myproj/internal/svc.Service).Handler$29:26", Package: "myproj/internal/svc"}, "myproj/internal/svc.(*Service).Handler line=29"},
+		{Symbol{Name: "myproj/internal/svc.Process$42:28", Package: "myproj/internal/svc"}, "myproj/internal/svc.Process line=42"},
+		{Symbol{Name: "example.com/m.Run", Package: "example.com/m"}, "example.com/m.Run"},
+	}
+	for _, tt := range tests {
+		if got := fetchSelector(tt.sym); got != tt.want {
+			t.Errorf("fetchSelector(%q) = %q, want %q", tt.sym.Name, got, tt.want)
+		}
+	}
+}
+
+func TestSignatureOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "function",
+			source: "func Add(a, b int) int {\n\treturn a + b\n}",
+			want:   "func Add(a, b int) int",
+			wantOK: true,
+		},
+		{
+			name:   "method with doc comment",
+			source: "// Get returns the value.\nfunc (s *Store) Get(key string) (string, error) {\n\t// Look it up.\n\treturn s.m[key], nil\n}",
+			want:   "// Get returns the value.\nfunc (s *Store) Get(key string) (string, error)",
+			wantOK: true,
+		},
+		{
+			name:   "generic function",
+			source: "func Map[T, U any](xs []T, f func(T) U) []U {\n\treturn nil\n}",
+			want:   "func Map[T, U any](xs []T, f func(T) U) []U",
+			wantOK: true,
+		},
+		{
+			name:   "function literal",
+			source: "func(w http.ResponseWriter, r *http.Request) {\n\tw.WriteHeader(200)\n}",
+			want:   "func(w http.ResponseWriter, r *http.Request)",
+			wantOK: true,
+		},
+		{name: "empty", source: ""},
+		{name: "type declaration", source: "type T struct{}"},
+		{name: "declaration without body", source: "func Asm() int"},
+		{name: "expression", source: "a + b"},
+		{name: "invalid", source: "func {"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := signatureOf(tt.source)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("signatureOf() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFitReport(t *testing.T) {
+	pkgs := loadFixture(t, "budget")
+	target := fixtureTarget(t, pkgs, "budget", "budget.go", "Entry")
+	build := func() *Report {
+		report, err := BuildReport(target, "budget.go", 1, pkgs, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+	full := build()
+	rendered, err := RenderReport(full, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("within budget", func(t *testing.T) {
+		report := build()
+		if err := FitReport(report, Options{MaxBytes: len(rendered)}); err != nil {
+			t.Fatal(err)
+		}
+		if report.Notice != "" || trimLevels(report)["example.com/budget.far"] != "" {
+			t.Errorf("report within its budget was trimmed: %+v", trimLevels(report))
+		}
+	})
+
+	t.Run("farthest collapses first", func(t *testing.T) {
+		report := build()
+		if err := FitReport(report, Options{MaxBytes: len(rendered) - 1}); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{
+			"(*example.com/budget.Store).Add": "",
+			"example.com/budget.near":         "",
+			"example.com/budget.far":          TrimSignature,
+		}
+		if got := trimLevels(report); !maps.Equal(got, want) {
+			t.Errorf("trim levels = %v, want %v", got, want)
+		}
+		if report.Notice == "" {
+			t.Error("trimmed report has no notice")
+		}
+	})
+
+	// Whatever the budget, every source is collapsed before any is omitted,
+	// and the target keeps its full source.
+	for _, budget := range []int{len(rendered) * 3 / 4, len(rendered) / 2, 1} {
+		report := build()
+		if err := FitReport(report, Options{MaxBytes: budget}); err != nil {
+			t.Fatal(err)
+		}
+		levels := trimLevels(report)
+		omitted := false
+		for _, level := range levels {
+			omitted = omitted || level == TrimOmitted
+		}
+		for name, level := range levels {
+			if omitted && level == "" {
+				t.Errorf("budget %d: %s kept its full source while others were omitted", budget, name)
+			}
+		}
+		if report.Target.Source != full.Target.Source {
+			t.Errorf("budget %d: target source was trimmed to %q", budget, report.Target.Source)
+		}
+	}
+
+	t.Run("omitted selectors", func(t *testing.T) {
+		report := build()
+		if err := FitReport(report, Options{MaxBytes: 1}); err != nil {
+			t.Fatal(err)
+		}
+		for name, level := range trimLevels(report) {
+			if level != TrimOmitted {
+				t.Errorf("%s trimmed to %q, want %q", name, level, TrimOmitted)
+			}
+		}
+		text, err := RenderReport(report, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Every omitted function is listed with a selector func_code resolves
+		// back to the same function.
+		matches := regexp.MustCompile(`(?m)^- (\S+) \(.*\) \[func_code: (.+)\]$`).FindAllStringSubmatch(text, -1)
+		if len(matches) != len(report.Functions) {
+			t.Fatalf("omitted list has %d entries, want %d:\n%s", len(matches), len(report.Functions), text)
+		}
+		for _, m := range matches {
+			found, err := FindSymbol(pkgs, m[2])
+			if err != nil {
+				t.Errorf("selector %q of %s: %v", m[2], m[1], err)
+				continue
+			}
+			if got := found.Pkg.TypesInfo.ObjectOf(found.Fn.Name).(*types.Func).FullName(); got != m[1] {
+				t.Errorf("selector %q resolves to %s, want %s", m[2], got, m[1])
+			}
+		}
+	})
+}
+
+// trimLevels maps the dependency functions of a report to how far their
+// sources were trimmed.
+func trimLevels(report *Report) map[string]string {
+	levels := make(map[string]string)
+	for _, sym := range report.Functions {
+		levels[sym.Name] = sym.Trimmed
+	}
+	return levels
+}
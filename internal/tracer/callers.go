@@ -0,0 +1,106 @@
+// internal/tracer/callers.go
+package tracer
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// buildCallerIndex walks every function declared in the project packages, and
+// every function literal inside them, and returns a map from a callee's full
+// name to the names of the functions that reference it. If opts.Context stops
+// the walk, the callers found so far are returned with the reason.
+func buildCallerIndex(pkgs []*packages.Package, opts Options) (map[string][]string, error) {
+	projectPackages := make(map[string]bool)
+	for _, p := range pkgs {
+		projectPackages[p.PkgPath] = true
+	}
+	var resolver *interfaceResolver
+	if opts.ResolveInterfaces {
+		resolver = newInterfaceResolver(pkgs, projectPackages)
+	}
+
+	callers := make(map[string][]string)
+	for i, p := range pkgs {
+		if stopped := opts.stopped(); stopped != nil {
+			return callers, stopped
+		}
+		if i%progressInterval == 0 {
+			opts.progress("Indexing callers: package %d of %d", i+1, len(pkgs))
+		}
+		if p.TypesInfo == nil {
+			continue
+		}
+		for _, fileAST := range p.Syntax {
+			for _, decl := range fileAST.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				walkFuncDecl(p, fn, projectPackages, func(target AnalysisTarget, callerKey string, collector *resultCollector) {
+					var calleeKeys []string
+					for _, call := range collector.Calls {
+						calleeKeys = append(calleeKeys, call.Func.FullName())
+						if resolver == nil {
+							continue
+						}
+						for _, impl := range resolver.Implementations(call.Func) {
+							calleeKeys = append(calleeKeys, impl.FullName())
+						}
+					}
+					for _, closure := range collector.Closures {
+						if closureKey, err := targetKey(AnalysisTarget{Pkg: p, Fn: fn, Lit: closure.Lit}); err == nil {
+							calleeKeys = append(calleeKeys, closureKey)
+						}
+					}
+
+					seen := make(map[string]bool)
+					for _, calleeKey := range calleeKeys {
+						if seen[calleeKey] {
+							continue
+						}
+						seen[calleeKey] = true
+						callers[calleeKey] = append(callers[calleeKey], callerKey)
+					}
+				})
+			}
+		}
+	}
+	return callers, nil
+}
+
+// FindCallers finds all project functions and methods that call the target, with optional recursion.
+// A depth of 0 returns only the direct callers; each additional level adds the callers of those callers.
+func FindCallers(target AnalysisTarget, depth int, pkgs []*packages.Package, opts Options) ([]string, error) {
+	targetName, err := targetKey(target)
+	if err != nil {
+		return nil, err
+	}
+	index, stopped := buildCallerIndex(pkgs, opts)
+
+	type callerTask struct {
+		Key   string
+		Depth int
+	}
+	queue := []callerTask{{Key: targetName, Depth: 0}}
+	processed := map[string]bool{targetName: true}
+	var callerNames []string
+
+	// A caller index cut short still answers for the packages it covers.
+	for len(queue) > 0 {
+		currentTask := queue[0]
+		queue = queue[1:]
+		for _, callerKey := range index[currentTask.Key] {
+			if processed[callerKey] {
+				continue
+			}
+			processed[callerKey] = true
+			callerNames = append(callerNames, callerKey)
+			if currentTask.Depth < depth {
+				queue = append(queue, callerTask{Key: callerKey, Depth: currentTask.Depth + 1})
+			}
+		}
+	}
+	return callerNames, stopped
+}
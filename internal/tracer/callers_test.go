@@ -0,0 +1,44 @@
+package tracer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindCallers(t *testing.T) {
+	pkgs := loadFixture(t, "callers")
+	target := fixtureTarget(t, pkgs, "callers", "store/store.go", "Save")
+
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{0, []string{
+			"(example.com/callers/store.Repo).Put",
+			"(*example.com/callers/store.Repo).PutTwice",
+			"example.com/callers.Main",
+		}},
+		{1, []string{
+			"(example.com/callers/store.Repo).Put",
+			"(*example.com/callers/store.Repo).PutTwice",
+			"example.com/callers.Main",
+			"example.com/callers.Handle",
+		}},
+		{5, []string{
+			"(example.com/callers/store.Repo).Put",
+			"(*example.com/callers/store.Repo).PutTwice",
+			"example.com/callers.Main",
+			"example.com/callers.Handle",
+			"example.com/callers.Serve",
+		}},
+	}
+	for _, tt := range tests {
+		got, err := FindCallers(target, tt.depth, pkgs, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindCallers(depth %d) = %q, want %q", tt.depth, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// internal/tracer/cancel.go
+package tracer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTruncated is returned together with partial results when an analysis is
+// stopped by Options.Context, or gives up at a search limit, before it
+// completes. Analyses that return a report record the reason in its Truncated
+// field instead.
+var ErrTruncated = errors.New("analysis stopped before completion")
+
+// stopped returns an error wrapping ErrTruncated and the context's error once
+// opts.Context is cancelled or past its deadline, and nil until then.
+func (opts Options) stopped() error {
+	if opts.Context == nil {
+		return nil
+	}
+	if err := opts.Context.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrTruncated, err)
+	}
+	return nil
+}
+
+// truncation returns the reason stored in a report's Truncated field.
+func truncation(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,114 @@
+// internal/tracer/closures.go
+package tracer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// body returns the statements of the target: those of its function literal if
+// it has one, otherwise those of the declared function.
+func (t AnalysisTarget) body() *ast.BlockStmt {
+	if t.Lit != nil {
+		return t.Lit.Body
+	}
+	return t.Fn.Body
+}
+
+// targetKey returns the name identifying a target in call edges: the full name
+// of a declared function, or for a function literal the full name of the
+// declaring function followed by the literal's position, e.g.
+// "(*myproj/internal/svc.Service).Handler$29:26".
+func targetKey(target AnalysisTarget) (string, error) {
+	fnObj, ok := target.Pkg.TypesInfo.ObjectOf(target.Fn.Name).(*types.Func)
+	if !ok {
+		return "", fmt.Errorf("could not resolve function object for '%s'", target.Fn.Name.Name)
+	}
+	if target.Lit == nil {
+		return fnObj.FullName(), nil
+	}
+	return fnObj.FullName() + closureSuffix(target), nil
+}
+
+// closureSuffix returns the "$line:col" suffix naming a function literal.
+func closureSuffix(target AnalysisTarget) string {
+	position := target.Pkg.Fset.Position(target.Lit.Pos())
+	return fmt.Sprintf("$%d:%d", position.Line, position.Column)
+}
+
+// FindClosure returns the function literal inside the target function that
+// starts on the given line. When several literals start on that line, the
+// outermost one is returned.
+func FindClosure(target AnalysisTarget, line int) (AnalysisTarget, error) {
+	var found *ast.FuncLit
+	if target.Fn.Body != nil {
+		ast.Inspect(target.Fn.Body, func(n ast.Node) bool {
+			if found != nil {
+				return false
+			}
+			if lit, ok := n.(*ast.FuncLit); ok && target.Pkg.Fset.Position(lit.Pos()).Line == line {
+				found = lit
+				return false
+			}
+			return true
+		})
+	}
+	if found == nil {
+		return target, fmt.Errorf("no function literal starts on line %d of '%s'", line, displayName(target))
+	}
+	return AnalysisTarget{Pkg: target.Pkg, Fn: target.Fn, Lit: found}, nil
+}
+
+// getClosureSourceSnippet returns the source of a function literal.
+func getClosureSourceSnippet(pkg *packages.Package, lit *ast.FuncLit) (string, error) {
+	return formatWithComments(pkg, lit)
+}
+
+// newClosureSymbol describes a function literal named key.
+func newClosureSymbol(target AnalysisTarget, key string) Symbol {
+	sym := Symbol{Name: key, Package: target.Pkg.PkgPath}
+	setSymbolPosition(&sym, target.Pkg, target.Lit.Pos())
+	sym.EndLine = target.Pkg.Fset.Position(target.Lit.End()).Line
+	snippet, err := getClosureSourceSnippet(target.Pkg, target.Lit)
+	if err != nil {
+		sym.Error = err.Error()
+	} else {
+		sym.Source = snippet
+	}
+	return sym
+}
+
+// walkFuncDecl walks a function declaration and every function literal nested
+// in it, calling visit once per function with its key and the references
+// collected directly in its body.
+func walkFuncDecl(p *packages.Package, fn *ast.FuncDecl, projectPackages map[string]bool, visit func(target AnalysisTarget, key string, collector *resultCollector)) {
+	if fn.Body == nil {
+		return
+	}
+	queue := []AnalysisTarget{{Pkg: p, Fn: fn}}
+	for len(queue) > 0 {
+		target := queue[0]
+		queue = queue[1:]
+		key, err := targetKey(target)
+		if err != nil {
+			continue
+		}
+		collector := &resultCollector{
+			Info:            p.TypesInfo,
+			ProjectPackages: projectPackages,
+		}
+		// Like performRecursiveAnalysis, a declaration references the
+		// constraints of its own type parameters.
+		if fnObj, ok := p.TypesInfo.ObjectOf(fn.Name).(*types.Func); ok && target.Lit == nil {
+			collector.addConstraints(funcTypeParams(fnObj))
+		}
+		ast.Walk(collector, target.body())
+		visit(target, key, collector)
+		for _, closure := range collector.Closures {
+			queue = append(queue, AnalysisTarget{Pkg: p, Fn: fn, Lit: closure.Lit})
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package tracer
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestClosureNodes(t *testing.T) {
+	pkgs := loadFixture(t, "closures")
+	serve := fixtureTarget(t, pkgs, "closures", "closures.go", "Serve")
+
+	graph, err := ExtractCallGraph(serve, 0, pkgs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, edge := range graph.Edges {
+		edge.Position = filepath.Base(edge.Position)
+		got = append(got, edge.String())
+	}
+	sort.Strings(got)
+	// Function literals are nodes of their own, named after the enclosing
+	// function and their position, at the depth of the enclosing function.
+	want := []string{
+		"example.com/closures.Serve -> example.com/closures.Register [direct] at closures.go:12:2 (depth=0)",
+		"example.com/closures.Serve -> example.com/closures.Serve$12:11 [value] at closures.go:12:11 (depth=0)",
+		"example.com/closures.Serve -> example.com/closures.Serve$17:5 [go] at closures.go:17:5 (depth=0)",
+		"example.com/closures.Serve$12:11 -> example.com/closures.Serve$14:10 [value] at closures.go:14:10 (depth=0)",
+		"example.com/closures.Serve$12:11 -> example.com/closures.handle [direct] at closures.go:13:3 (depth=0)",
+		"example.com/closures.Serve$14:10 -> example.com/closures.audit [direct] at closures.go:14:19 (depth=0)",
+		"example.com/closures.Serve$17:5 -> example.com/closures.work [direct] at closures.go:17:14 (depth=0)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("edges:\n%s\nwant:\n%s", joinLines(got), joinLines(want))
+	}
+}
+
+func TestClosureTarget(t *testing.T) {
+	pkgs := loadFixture(t, "closures")
+	serve := fixtureTarget(t, pkgs, "closures", "closures.go", "Serve")
+
+	if _, err := FindClosure(serve, 11); err == nil {
+		t.Error("FindClosure found a literal on a line without one")
+	}
+	handler, err := FindClosure(serve, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := BuildReport(handler, "closures.go", 0, pkgs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := report.Target
+	if target.Name != "example.com/closures.Serve$12:11" || target.StartLine != 12 || target.EndLine != 16 ||
+		target.Source != "func() {\n\thandle()\n\tlog := func() { audit() }\n\tlog()\n}" {
+		t.Errorf("target = %+v", target)
+	}
+	var names []string
+	for _, sym := range report.Functions {
+		names = append(names, sym.Name)
+	}
+	sort.Strings(names)
+	want := []string{"example.com/closures.Serve$14:10", "example.com/closures.audit", "example.com/closures.handle"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("functions = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,133 @@
+// internal/tracer/comments.go
+package tracer
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// formatWithComments prints a node of pkg in gofmt style together with its doc
+// comment, the comments inside it and a trailing comment on its last line.
+// A bare node printed with format.Node keeps at most its doc comment.
+func formatWithComments(pkg *packages.Package, node ast.Node) (string, error) {
+	start := node.Pos()
+	if doc := docComment(node); doc != nil {
+		start = doc.Pos()
+	}
+	endLine := pkg.Fset.Position(node.End()).Line
+
+	var comments []*ast.CommentGroup
+	var trailing *ast.CommentGroup
+	for _, fileAST := range pkg.Syntax {
+		if fileAST.Pos() > node.Pos() || node.Pos() >= fileAST.End() {
+			continue
+		}
+		for _, group := range fileAST.Comments {
+			if group.Pos() < start {
+				continue
+			}
+			if group.Pos() >= node.End() {
+				if pkg.Fset.Position(group.Pos()).Line == endLine {
+					trailing = group
+				}
+				break
+			}
+			comments = append(comments, group)
+		}
+		break
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, pkg.Fset, &printer.CommentedNode{Node: node, Comments: comments}); err != nil {
+		return "", err
+	}
+	// The printer drops comments that follow the node's last token, so a
+	// trailing comment is appended by hand.
+	if trailing != nil {
+		for _, comment := range trailing.List {
+			buf.WriteString(" " + comment.Text)
+		}
+	}
+	return buf.String(), nil
+}
+
+// docComment returns the doc comment attached to a declaration, if any.
+func docComment(node ast.Node) *ast.CommentGroup {
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		return n.Doc
+	case *ast.GenDecl:
+		return n.Doc
+	case *ast.TypeSpec:
+		return n.Doc
+	case *ast.ValueSpec:
+		return n.Doc
+	}
+	return nil
+}
+
+// stripComments removes every comment from a snippet produced by
+// formatWithComments. Lines holding only a comment are dropped with it, and
+// trailing comments with the spaces before them, before the snippet is
+// reformatted. Snippets that cannot be parsed are returned unchanged.
+func stripComments(source string) string {
+	if source == "" {
+		return source
+	}
+	// Function literals are parsed as the value of a declaration.
+	fset := token.NewFileSet()
+	prefix := "package p\n"
+	file, err := parser.ParseFile(fset, "", prefix+source, parser.ParseComments)
+	if err != nil {
+		prefix = "package p\nvar _ = "
+		if file, err = parser.ParseFile(fset, "", prefix+source, parser.ParseComments); err != nil {
+			return source
+		}
+	}
+	tokFile := fset.File(file.Pos())
+
+	isBlank := func(b byte) bool { return b == ' ' || b == '\t' }
+	var out strings.Builder
+	last := 0
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			start := tokFile.Offset(comment.Pos()) - len(prefix)
+			end := tokFile.Offset(comment.End()) - len(prefix)
+			for start > last && isBlank(source[start-1]) {
+				start--
+			}
+			lineEnd := end
+			for lineEnd < len(source) && isBlank(source[lineEnd]) {
+				lineEnd++
+			}
+			atLineStart := start == 0 || source[start-1] == '\n'
+			atLineEnd := lineEnd == len(source) || source[lineEnd] == '\n'
+			switch {
+			case atLineStart && atLineEnd:
+				end = min(lineEnd+1, len(source))
+			case !atLineEnd:
+				// An inline /* */ comment keeps the spaces around it.
+				start = max(tokFile.Offset(comment.Pos())-len(prefix), last)
+			}
+			out.WriteString(source[last:start])
+			last = end
+		}
+	}
+	out.WriteString(source[last:])
+
+	// Reformat to realign the fields and values the comments were aligned with.
+	formatted, err := format.Source([]byte(prefix + out.String()))
+	if err != nil {
+		return out.String()
+	}
+	_, stripped, _ := strings.Cut(string(formatted), "\n\n")
+	stripped = strings.TrimPrefix(stripped, "var _ = ")
+	return strings.TrimSuffix(stripped, "\n")
+}
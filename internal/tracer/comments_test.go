@@ -0,0 +1,94 @@
+package tracer
+
+import "testing"
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "doc and line comments",
+			source: "// Add adds.\nfunc Add(a, b int) int {\n\t// Sum them.\n\treturn a + b // the result\n}",
+			want:   "func Add(a, b int) int {\n\treturn a + b\n}",
+		},
+		{
+			name:   "inline block comment",
+			source: "func F() {\n\tg(1, /* retries */ 2)\n}",
+			want:   "func F() {\n\tg(1, 2)\n}",
+		},
+		{
+			name:   "aligned struct fields",
+			source: "type T struct {\n\tID   int    // Identifier.\n\tName string // Display name.\n}",
+			want:   "type T struct {\n\tID   int\n\tName string\n}",
+		},
+		{
+			name:   "realigned after a long comment line",
+			source: "var (\n\ta    = 1 // one\n\t// b is long.\n\tlong = 2\n)",
+			want:   "var (\n\ta    = 1\n\tlong = 2\n)",
+		},
+		{
+			name:   "function literal",
+			source: "func() {\n\t// Work.\n\twork()\n}",
+			want:   "func() {\n\twork()\n}",
+		},
+		{
+			name:   "comment marker in a string",
+			source: "func F() string {\n\treturn \"// not a comment\"\n}",
+			want:   "func F() string {\n\treturn \"// not a comment\"\n}",
+		},
+		{
+			name:   "unparsable",
+			source: "func {\n\t// kept\n",
+			want:   "func {\n\t// kept\n",
+		},
+		{name: "empty", source: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripComments(tt.source); got != tt.want {
+				t.Errorf("stripComments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportComments(t *testing.T) {
+	pkgs := loadFixture(t, "comments")
+	target := fixtureTarget(t, pkgs, "comments", "comments.go", "Move")
+
+	tests := []struct {
+		strip  bool
+		target string
+		point  string
+	}{
+		{
+			// Doc, inline and trailing comments of the function and the
+			// field comments of the type are kept; the doc comment of the
+			// next declaration is not.
+			strip: false,
+			target: "// Move shifts p by one column.\nfunc Move(p Point) Point {\n\t// Columns grow to the right.\n\tp.X += 1 /* one step */\n" +
+				"\treturn Point{X: p.X, Y: p.Y}\n} // Move never fails.",
+			point: "// Point is a position on the grid.\ntype Point struct {\n\tX int // Column.\n\t// Y is the row, counted from the top.\n\tY int\n" +
+				"} // Point is compared by value.",
+		},
+		{
+			strip:  true,
+			target: "func Move(p Point) Point {\n\tp.X += 1\n\treturn Point{X: p.X, Y: p.Y}\n}",
+			point:  "type Point struct {\n\tX int\n\tY int\n}",
+		},
+	}
+	for _, tt := range tests {
+		report, err := BuildReport(target, "comments.go", 0, pkgs, Options{StripComments: tt.strip})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Target.Source != tt.target {
+			t.Errorf("strip=%v: target source = %q, want %q", tt.strip, report.Target.Source, tt.target)
+		}
+		if len(report.Types) != 1 || report.Types[0].Source != tt.point {
+			t.Errorf("strip=%v: types = %+v, want Point with source %q", tt.strip, report.Types, tt.point)
+		}
+	}
+}
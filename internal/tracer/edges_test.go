@@ -0,0 +1,39 @@
+package tracer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractCallGraph(t *testing.T) {
+	pkgs := loadFixture(t, "edges")
+	entry := fixtureTarget(t, pkgs, "edges", "edges.go", "Entry")
+
+	graph, err := ExtractCallGraph(entry, 1, pkgs, Options{ResolveInterfaces: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.Root != "example.com/edges.Entry" {
+		t.Errorf("Root = %q", graph.Root)
+	}
+	var got []string
+	for _, edge := range graph.Edges {
+		edge.Position = filepath.Base(edge.Position)
+		got = append(got, edge.String())
+	}
+	want := []string{
+		"example.com/edges.Entry -> example.com/edges.step [direct] at edges.go:20:2 (depth=0)",
+		"example.com/edges.Entry -> example.com/edges.cleanup [defer] at edges.go:21:8 (depth=0)",
+		"example.com/edges.Entry -> example.com/edges.worker [go] at edges.go:22:5 (depth=0)",
+		"example.com/edges.Entry -> example.com/edges.apply [direct] at edges.go:23:2 (depth=0)",
+		"example.com/edges.Entry -> example.com/edges.step [value] at edges.go:23:8 (depth=0)",
+		"example.com/edges.Entry -> (example.com/edges.job).Run [value] at edges.go:25:11 (depth=0)",
+		"example.com/edges.Entry -> (example.com/edges.Runner).Run [direct] at edges.go:27:4 (depth=0)",
+		"example.com/edges.Entry -> (example.com/edges.job).Run [direct] at edges.go:27:4 (depth=0) via (example.com/edges.Runner).Run",
+		"(example.com/edges.job).Run -> example.com/edges.step [direct] at edges.go:9:20 (depth=1)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("edges:\n%s\nwant:\n%s", joinLines(got), joinLines(want))
+	}
+}
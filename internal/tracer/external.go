@@ -0,0 +1,213 @@
+// internal/tracer/external.go
+package tracer
+
+import (
+	"context"
+	"go/ast"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// externalFilter decides which packages outside the project are followed.
+type externalFilter struct {
+	Allow []string
+	Deny  []string
+}
+
+// newExternalFilter returns the filter configured by opts, or nil if external
+// calls are not recorded.
+func newExternalFilter(opts Options) *externalFilter {
+	if opts.External == ExternalNone {
+		return nil
+	}
+	return &externalFilter{Allow: opts.ExternalAllow, Deny: opts.ExternalDeny}
+}
+
+// Match reports whether a dependency package is followed: it must match no deny
+// pattern and, if allow patterns are given, at least one of them.
+func (f *externalFilter) Match(pkgPath string) bool {
+	for _, pattern := range f.Deny {
+		if matchPackagePattern(pattern, pkgPath) {
+			return false
+		}
+	}
+	if len(f.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range f.Allow {
+		if matchPackagePattern(pattern, pkgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPackagePattern reports whether a package path matches a pattern in the
+// style of the go command, where "..." matches any string and a trailing "/..."
+// also matches the package itself, e.g. "net/http/..." matches "net/http".
+func matchPackagePattern(pattern, pkgPath string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if !strings.Contains(pattern, "...") {
+		return pattern == pkgPath
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok && pkgPath == prefix {
+		return true
+	}
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\.\.\.`, `.*`)
+	matched, _ := regexp.MatchString("^"+expr+"$", pkgPath)
+	return matched
+}
+
+// DependencyCache keeps the dependency packages loaded for one project, so that
+// analyses of the project share them instead of loading them again. It is safe
+// for concurrent use. Packages that cannot be loaded are remembered as nil.
+type DependencyCache struct {
+	mu   sync.Mutex
+	pkgs map[string]*packages.Package
+}
+
+// NewDependencyCache returns an empty cache.
+func NewDependencyCache() *DependencyCache {
+	return &DependencyCache{pkgs: make(map[string]*packages.Package)}
+}
+
+// dependencySources loads the source of dependency packages on demand, so that
+// external functions can be shown with their doc comments and traced into.
+type dependencySources struct {
+	Dir     string          // Directory the go command is run from, inside the project module.
+	Context context.Context // Cancels loading; nil if loading cannot be cancelled.
+	cache   *DependencyCache
+}
+
+// newDependencySources prepares a loader that resolves dependencies the same
+// way the project packages were resolved, keeping them in cache, or in a cache
+// of its own if cache is nil.
+func newDependencySources(pkgs []*packages.Package, cache *DependencyCache) *dependencySources {
+	if cache == nil {
+		cache = NewDependencyCache()
+	}
+	deps := &dependencySources{cache: cache}
+	for _, p := range pkgs {
+		if len(p.GoFiles) > 0 {
+			deps.Dir = filepath.Dir(p.GoFiles[0])
+			break
+		}
+	}
+	return deps
+}
+
+// Package returns the type-checked source of a dependency package, or nil if it
+// could not be loaded.
+func (d *dependencySources) Package(pkgPath string) *packages.Package {
+	d.Load([]string{pkgPath})
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+	return d.cache.pkgs[pkgPath]
+}
+
+// Load loads the given dependency packages that are not cached yet with a
+// single run of the go command. A load stopped by the context is not cached.
+func (d *dependencySources) Load(pkgPaths []string) {
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+	var missing []string
+	for _, pkgPath := range pkgPaths {
+		if _, ok := d.cache.pkgs[pkgPath]; !ok {
+			missing = append(missing, pkgPath)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	cfg := &packages.Config{Mode: packages.LoadSyntax | packages.LoadTypes | packages.LoadFiles, Dir: d.Dir, Context: d.Context}
+	loaded, err := packages.Load(cfg, missing...)
+	if d.Context != nil && d.Context.Err() != nil {
+		return
+	}
+	found := make(map[string]*packages.Package)
+	if err == nil {
+		for _, p := range loaded {
+			if p.TypesInfo != nil && len(p.Errors) == 0 {
+				found[p.PkgPath] = p
+			}
+		}
+	}
+	for _, pkgPath := range missing {
+		d.cache.pkgs[pkgPath] = found[pkgPath]
+	}
+}
+
+// FuncDecl locates the declaration of an external function or method in the
+// source of its package. Objects from the project's type-checking and from the
+// dependency's own are distinct, so the declaration is matched by name.
+func (d *dependencySources) FuncDecl(fn *types.Func) (*packages.Package, *ast.FuncDecl) {
+	p := d.Package(fn.Pkg().Path())
+	if p == nil {
+		return nil, nil
+	}
+	recv := ""
+	if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+		recvType := sig.Recv().Type()
+		if ptr, ok := recvType.(*types.Pointer); ok {
+			recvType = ptr.Elem()
+		}
+		if named, ok := recvType.(interface{ Obj() *types.TypeName }); ok {
+			recv = named.Obj().Name()
+		}
+	}
+	for _, fileAST := range p.Syntax {
+		for _, decl := range fileAST.Decls {
+			fnDecl, ok := decl.(*ast.FuncDecl)
+			if ok && fnDecl.Name.Name == fn.Name() && receiverTypeName(fnDecl) == recv {
+				return p, fnDecl
+			}
+		}
+	}
+	return p, nil
+}
+
+// newExternalSymbol describes a function outside the project. Traced functions
+// carry their full source; leaf functions their signature and doc comment.
+// Functions without a declaration in source, such as interface methods, are
+// described by their type signature.
+func newExternalSymbol(deps *dependencySources, fun *types.Func, traced bool) Symbol {
+	sym := funcSymbolName(fun)
+	p, decl := deps.FuncDecl(fun)
+	if decl == nil {
+		sym.Source = types.ObjectString(fun, nil)
+		return sym
+	}
+	setSymbolPosition(&sym, p, decl.Name.Pos())
+	sym.StartLine = p.Fset.Position(decl.Pos()).Line
+	sym.EndLine = p.Fset.Position(decl.End()).Line
+	var snippet string
+	var err error
+	if traced {
+		snippet, err = getFuncSourceSnippet(p, decl.Name.Pos())
+	} else {
+		snippet, err = getFuncSignatureSnippet(p, decl.Name.Pos(), true)
+	}
+	if err != nil {
+		sym.Error = err.Error()
+	} else {
+		sym.Source = snippet
+	}
+	return sym
+}
+
+// ParsePackagePatterns splits a comma-separated list of package patterns,
+// dropping empty entries.
+func ParsePackagePatterns(list string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
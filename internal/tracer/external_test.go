@@ -0,0 +1,30 @@
+package tracer
+
+import "testing"
+
+func TestMatchPackagePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		pkgPath string
+		want    bool
+	}{
+		{"net/http", "net/http", true},
+		{"net/http", "net/http/httptest", false},
+		{"net/http/...", "net/http", true},
+		{"net/http/...", "net/http/httptest", true},
+		{"net/http/...", "net/httpx", false},
+		{"net/...", "net/url", true},
+		{"github.com/aws/...", "github.com/aws/aws-sdk-go/service/s3", true},
+		{"github.com/aws/...", "github.com/awslabs/smithy-go", false},
+		{"example.com/.../internal", "example.com/svc/internal", true},
+		{"example.com/.../internal", "example.com/svc/internal/db", false},
+		{" fmt ", "fmt", true},
+		{"fmt", "fmtx", false},
+		{"k8s.io/api...", "k8s.io/apimachinery", true},
+	}
+	for _, tt := range tests {
+		if got := matchPackagePattern(tt.pattern, tt.pkgPath); got != tt.want {
+			t.Errorf("matchPackagePattern(%q, %q) = %v, want %v", tt.pattern, tt.pkgPath, got, tt.want)
+		}
+	}
+}
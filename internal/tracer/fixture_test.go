@@ -0,0 +1,47 @@
+package tracer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadFixture loads the packages of a module under testdata.
+func loadFixture(t *testing.T, name string) []*packages.Package {
+	t.Helper()
+	dir, err := filepath.Abs(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &packages.Config{Mode: packages.LoadSyntax | packages.LoadTypes | packages.LoadFiles, Dir: dir}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		t.Fatalf("loading %s: %v", name, err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		t.Fatalf("errors loading %s", name)
+	}
+	return pkgs
+}
+
+// fixtureTarget finds a function declared in a file of a fixture module, with
+// the file given relative to the module root.
+func fixtureTarget(t *testing.T, pkgs []*packages.Package, fixture, file, funcName string) AnalysisTarget {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join("testdata", fixture, filepath.FromSlash(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := FindTarget(pkgs, path, funcName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+// joinLines formats a list one item per line for test failure messages.
+func joinLines(lines []string) string {
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,65 @@
+// internal/tracer/generics.go
+package tracer
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// funcTypeParams returns the type parameters of a generic function, or the
+// receiver type parameters of a method declared on a generic type.
+func funcTypeParams(fn *types.Func) *types.TypeParamList {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok {
+		return nil
+	}
+	if sig.TypeParams().Len() > 0 {
+		return sig.TypeParams()
+	}
+	return sig.RecvTypeParams()
+}
+
+// typeArguments describes the type arguments a generic function, or a method of
+// a generic type, is instantiated with at ident, as "T=int" pairs. It returns
+// nil for non-generic callees.
+func (v *resultCollector) typeArguments(ident *ast.Ident, fn *types.Func) []string {
+	var params *types.TypeParamList
+	var args *types.TypeList
+	if inst, ok := v.Info.Instances[ident]; ok {
+		params, args = funcTypeParams(fn.Origin()), inst.TypeArgs
+	} else if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+		recv := sig.Recv().Type()
+		if ptr, ok := recv.(*types.Pointer); ok {
+			recv = ptr.Elem()
+		}
+		if named, ok := recv.(*types.Named); ok && named.TypeArgs().Len() > 0 {
+			params, args = named.Origin().TypeParams(), named.TypeArgs()
+		}
+	}
+	if params == nil || args == nil || params.Len() != args.Len() {
+		return nil
+	}
+	typeArgs := make([]string, args.Len())
+	for i := 0; i < args.Len(); i++ {
+		typeArgs[i] = params.At(i).Obj().Name() + "=" + types.TypeString(args.At(i), nil)
+	}
+	return typeArgs
+}
+
+// addConstraints records the project types named in the constraints of the
+// given type parameters as referenced types.
+func (v *resultCollector) addConstraints(tparams *types.TypeParamList) {
+	for i := 0; i < tparams.Len(); i++ {
+		v.addConstraint(tparams.At(i))
+	}
+}
+
+// addConstraint records the project types named in the constraint of a type
+// parameter as referenced types.
+func (v *resultCollector) addConstraint(tparam *types.TypeParam) {
+	collectNamedTypes(tparam.Constraint(), func(typeName *types.TypeName) {
+		if typeName.Pkg() != nil && v.ProjectPackages[typeName.Pkg().Path()] {
+			v.ReferencedTypes = append(v.ReferencedTypes, typeName)
+		}
+	})
+}
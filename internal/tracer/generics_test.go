@@ -0,0 +1,66 @@
+package tracer
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGenericCallees(t *testing.T) {
+	pkgs := loadFixture(t, "generics")
+	use := fixtureTarget(t, pkgs, "generics", "generics.go", "Use")
+
+	graph, err := ExtractCallGraph(use, 1, pkgs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, edge := range graph.Edges {
+		edge.Position = filepath.Base(edge.Position)
+		got = append(got, edge.String())
+	}
+	// Instantiated callees are traced through their generic origin, and the
+	// edge records the type arguments of the instantiation.
+	want := []string{
+		"example.com/generics.Use -> example.com/generics.Sum [direct] at generics.go:36:2 (depth=0) with [T=int]",
+		"example.com/generics.Use -> example.com/generics.Tag [direct] at generics.go:37:2 (depth=0) with [T=example.com/generics.ID]",
+		"example.com/generics.Use -> (*example.com/generics.Box[T]).Set [direct] at generics.go:39:4 (depth=0) with [T=string]",
+		"example.com/generics.Use -> (example.com/generics.Box[T]).Get [direct] at generics.go:40:8 (depth=0) with [T=string]",
+		"example.com/generics.Tag -> (example.com/generics.Labeler).Label [direct] at generics.go:23:44 (depth=1)",
+		"(example.com/generics.Box[T]).Get -> example.com/generics.identity [direct] at generics.go:31:34 (depth=1) with [T=T]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("edges:\n%s\nwant:\n%s", joinLines(got), joinLines(want))
+	}
+}
+
+func TestGenericConstraintTypes(t *testing.T) {
+	pkgs := loadFixture(t, "generics")
+
+	tests := []struct {
+		target string
+		depth  int
+		want   []string
+	}{
+		// The target's own constraints count as referenced types.
+		{"Sum", 0, []string{"Number"}},
+		// Constraints of instantiated callees come along with their type arguments.
+		{"Use", 0, []string{"Box", "ID", "Labeler", "Number"}},
+	}
+	for _, tt := range tests {
+		target := fixtureTarget(t, pkgs, "generics", "generics.go", tt.target)
+		got, err := ExtractTypes(target, tt.depth, pkgs, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, name := range got {
+			got[i] = strings.TrimPrefix(name, "example.com/generics.")
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractTypes(%s, depth %d) = %q, want %q", tt.target, tt.depth, got, tt.want)
+		}
+	}
+}
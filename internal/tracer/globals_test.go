@@ -0,0 +1,59 @@
+package tracer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestExtractGlobals(t *testing.T) {
+	pkgs := loadFixture(t, "globals")
+	run := fixtureTarget(t, pkgs, "globals", "globals.go", "Run")
+
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{0, []string{"example.com/globals.counter", "example.com/globals.name"}},
+		{1, []string{"example.com/globals.Green", "example.com/globals.Limit", "example.com/globals.counter", "example.com/globals.name"}},
+	}
+	for _, tt := range tests {
+		got, err := ExtractGlobals(run, tt.depth, pkgs, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractGlobals(depth %d) = %q, want %q", tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestReportGlobals(t *testing.T) {
+	pkgs := loadFixture(t, "globals")
+	run := fixtureTarget(t, pkgs, "globals", "globals.go", "Run")
+
+	report, err := BuildReport(run, "globals.go", 1, pkgs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type global struct {
+		StartLine, EndLine int
+		Source             string
+	}
+	got := make(map[string]global)
+	for _, sym := range report.Globals {
+		got[sym.Name] = global{sym.StartLine, sym.EndLine, sym.Source}
+	}
+	// Values declared in a group are shown with the whole group, so that
+	// iota and implicit repetition stay readable.
+	want := map[string]global{
+		"example.com/globals.counter": {5, 5, "var counter int"},
+		"example.com/globals.name":    {7, 10, "var (\n\tname  = \"worker\"\n\tdebug bool\n)"},
+		"example.com/globals.Limit":   {3, 3, "const Limit = 10"},
+		"example.com/globals.Green":   {12, 15, "const (\n\tRed = iota\n\tGreen\n)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("globals = %+v, want %+v", got, want)
+	}
+}
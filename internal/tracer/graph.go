@@ -0,0 +1,205 @@
+// internal/tracer/graph.go
+package tracer
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// RenderCallGraph renders the call graph of the target as a DOT or Mermaid
+// diagram, selected by opts.Format. Unlike Analyze with the same format, it
+// collects only the names of the functions, not their sources.
+func RenderCallGraph(target AnalysisTarget, depth int, pkgs []*packages.Package, opts Options) (string, error) {
+	if opts.Format != FormatDOT && opts.Format != FormatMermaid {
+		return "", fmt.Errorf("unknown graph format '%s'", opts.Format)
+	}
+	results, err := performRecursiveAnalysis(target, depth, pkgs, opts)
+	if err != nil {
+		return "", err
+	}
+
+	report := &Report{Edges: results.Edges, Truncated: truncation(results.Stopped)}
+	if target.Lit != nil {
+		if key, err := targetKey(target); err == nil {
+			report.Target = Symbol{Name: key, Package: target.Pkg.PkgPath}
+		}
+	} else if fun, ok := target.Pkg.TypesInfo.ObjectOf(target.Fn.Name).(*types.Func); ok {
+		report.Target = funcSymbolName(fun)
+	}
+	funcKeys := orderedKeys(results.CalledFuncs, results.Order, opts.Order)
+	for key := range results.Closures {
+		funcKeys = append(funcKeys, key)
+	}
+	for _, key := range results.Order.sort(funcKeys, opts.Order) {
+		if closure, ok := results.Closures[key]; ok {
+			report.Functions = append(report.Functions, Symbol{Name: key, Package: closure.Pkg.PkgPath})
+			continue
+		}
+		report.Functions = append(report.Functions, funcSymbolName(results.CalledFuncs[key]))
+	}
+	for _, key := range orderedKeys(results.ExternalFuncs, results.Order, opts.Order) {
+		report.External = append(report.External, funcSymbolName(results.ExternalFuncs[key]))
+	}
+	return RenderReport(report, opts)
+}
+
+// graphNode is a function or method drawn in a call-graph diagram.
+type graphNode struct {
+	ID      string
+	Label   string
+	Package string
+}
+
+// graphEdge is a deduplicated caller -> callee arrow in a call-graph diagram.
+type graphEdge struct {
+	From string
+	To   string
+	Kind CallKind
+}
+
+// callGraphView lays out the nodes, package groups and edges of a report for the diagram renderers.
+type callGraphView struct {
+	Nodes    []graphNode
+	Packages []string            // Packages in order of first appearance.
+	ByPkg    map[string][]string // Node IDs grouped by package.
+	Edges    []graphEdge
+}
+
+// newCallGraphView collects the target, the called functions and the call edges of a report.
+func newCallGraphView(report *Report) *callGraphView {
+	view := &callGraphView{ByPkg: make(map[string][]string)}
+	ids := make(map[string]string)
+	addNode := func(sym Symbol) string {
+		if id, ok := ids[sym.Name]; ok {
+			return id
+		}
+		id := fmt.Sprintf("n%d", len(view.Nodes))
+		ids[sym.Name] = id
+		view.Nodes = append(view.Nodes, graphNode{ID: id, Label: symbolLabel(sym), Package: sym.Package})
+		if _, ok := view.ByPkg[sym.Package]; !ok {
+			view.Packages = append(view.Packages, sym.Package)
+		}
+		view.ByPkg[sym.Package] = append(view.ByPkg[sym.Package], id)
+		return id
+	}
+
+	addNode(report.Target)
+	for _, sym := range report.Functions {
+		addNode(sym)
+	}
+	for _, sym := range report.External {
+		addNode(sym)
+	}
+
+	seen := make(map[graphEdge]bool)
+	for _, edge := range report.Edges {
+		from := addNode(Symbol{Name: edge.Caller})
+		to := addNode(Symbol{Name: edge.Callee})
+		ge := graphEdge{From: from, To: to, Kind: edge.Kind}
+		if seen[ge] {
+			continue
+		}
+		seen[ge] = true
+		view.Edges = append(view.Edges, ge)
+	}
+	return view
+}
+
+// symbolLabel returns a short label for a function: its name, or (Receiver).Name for methods.
+func symbolLabel(sym Symbol) string {
+	name := sym.Name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if sym.Receiver != "" {
+		return fmt.Sprintf("(%s).%s", sym.Receiver, name)
+	}
+	return name
+}
+
+// renderDOT renders the call graph of a report as a Graphviz digraph, with one cluster per package.
+func renderDOT(report *Report) string {
+	view := newCallGraphView(report)
+	var out strings.Builder
+	if report.Truncated != "" {
+		out.WriteString(fmt.Sprintf("// Partial result: %s\n", report.Truncated))
+	}
+	out.WriteString("digraph calls {\n")
+	out.WriteString("\trankdir=LR;\n")
+	out.WriteString("\tnode [shape=box];\n")
+
+	labels := make(map[string]string)
+	for _, node := range view.Nodes {
+		labels[node.ID] = node.Label
+	}
+	for i, pkg := range view.Packages {
+		indent := "\t"
+		if pkg != "" {
+			out.WriteString(fmt.Sprintf("\tsubgraph cluster_%d {\n", i))
+			out.WriteString(fmt.Sprintf("\t\tlabel=%q;\n", pkg))
+			indent = "\t\t"
+		}
+		for _, id := range view.ByPkg[pkg] {
+			out.WriteString(fmt.Sprintf("%s%s [label=%q];\n", indent, id, labels[id]))
+		}
+		if pkg != "" {
+			out.WriteString("\t}\n")
+		}
+	}
+
+	for _, edge := range view.Edges {
+		switch edge.Kind {
+		case CallDirect:
+			out.WriteString(fmt.Sprintf("\t%s -> %s;\n", edge.From, edge.To))
+		case CallValue:
+			out.WriteString(fmt.Sprintf("\t%s -> %s [label=%q, style=dashed];\n", edge.From, edge.To, edge.Kind))
+		default:
+			out.WriteString(fmt.Sprintf("\t%s -> %s [label=%q];\n", edge.From, edge.To, edge.Kind))
+		}
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// renderMermaid renders the call graph of a report as a Mermaid flowchart, with one subgraph per package.
+func renderMermaid(report *Report) string {
+	view := newCallGraphView(report)
+	var out strings.Builder
+	out.WriteString("flowchart LR\n")
+	if report.Truncated != "" {
+		out.WriteString(fmt.Sprintf("    %%%% Partial result: %s\n", report.Truncated))
+	}
+
+	labels := make(map[string]string)
+	for _, node := range view.Nodes {
+		labels[node.ID] = node.Label
+	}
+	for i, pkg := range view.Packages {
+		indent := "    "
+		if pkg != "" {
+			out.WriteString(fmt.Sprintf("    subgraph g%d [\"%s\"]\n", i, pkg))
+			indent = "        "
+		}
+		for _, id := range view.ByPkg[pkg] {
+			out.WriteString(fmt.Sprintf("%s%s[\"%s\"]\n", indent, id, labels[id]))
+		}
+		if pkg != "" {
+			out.WriteString("    end\n")
+		}
+	}
+
+	for _, edge := range view.Edges {
+		switch edge.Kind {
+		case CallDirect:
+			out.WriteString(fmt.Sprintf("    %s --> %s\n", edge.From, edge.To))
+		case CallValue:
+			out.WriteString(fmt.Sprintf("    %s -.->|%s| %s\n", edge.From, edge.Kind, edge.To))
+		default:
+			out.WriteString(fmt.Sprintf("    %s -->|%s| %s\n", edge.From, edge.Kind, edge.To))
+		}
+	}
+	return out.String()
+}
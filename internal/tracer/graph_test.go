@@ -0,0 +1,81 @@
+package tracer
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// diagramEdges lists the edges of a DOT or Mermaid diagram with node IDs
+// replaced by their labels, sorted, so diagrams compare independently of the
+// order nodes were numbered in.
+func diagramEdges(t *testing.T, diagram string, node, edge *regexp.Regexp) []string {
+	t.Helper()
+	labels := make(map[string]string)
+	for _, m := range node.FindAllStringSubmatch(diagram, -1) {
+		labels[m[1]] = m[2]
+	}
+	var edges []string
+	for _, m := range edge.FindAllStringSubmatch(diagram, -1) {
+		from, to := labels[m[edge.SubexpIndex("from")]], labels[m[edge.SubexpIndex("to")]]
+		if from == "" || to == "" {
+			t.Fatalf("edge %q refers to an undeclared node", m[0])
+		}
+		edges = append(edges, strings.Join(strings.Fields(from+" "+m[edge.SubexpIndex("kind")]+" "+to), " "))
+	}
+	sort.Strings(edges)
+	return edges
+}
+
+func TestRenderCallGraph(t *testing.T) {
+	pkgs := loadFixture(t, "edges")
+	entry := fixtureTarget(t, pkgs, "edges", "edges.go", "Entry")
+
+	tests := []struct {
+		format string
+		node   *regexp.Regexp
+		edge   *regexp.Regexp
+		header string
+		want   []string
+	}{
+		{
+			format: FormatDOT,
+			node:   regexp.MustCompile(`(?m)^\s*(n\d+) \[label="([^"]+)"\];`),
+			edge:   regexp.MustCompile(`(?P<from>n\d+) -> (?P<to>n\d+)(?: \[label="(?P<kind>\w+)"(?:, style=dashed)?\])?;`),
+			header: "digraph calls {\n\trankdir=LR;\n\tnode [shape=box];\n\tsubgraph cluster_0 {\n\t\tlabel=\"example.com/edges\";\n",
+		},
+		{
+			format: FormatMermaid,
+			node:   regexp.MustCompile(`(n\d+)\["([^"]+)"\]`),
+			edge:   regexp.MustCompile(`(?P<from>n\d+) (?:-->|-\.->)(?:\|(?P<kind>\w+)\|)? (?P<to>n\d+)`),
+			header: "flowchart LR\n    subgraph g0 [\"example.com/edges\"]\n",
+		},
+	}
+	want := []string{
+		"(job).Run step",
+		"Entry (Runner).Run",
+		"Entry (job).Run",
+		"Entry apply",
+		"Entry defer cleanup",
+		"Entry go worker",
+		"Entry step",
+		"Entry value (job).Run",
+		"Entry value step",
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := Analyze(entry, "edges.go", 1, pkgs, Options{Format: tt.format, ResolveInterfaces: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(out, tt.header) {
+				t.Errorf("diagram does not start with %q:\n%s", tt.header, out)
+			}
+			if got := diagramEdges(t, out, tt.node, tt.edge); !reflect.DeepEqual(got, want) {
+				t.Errorf("edges:\n%s\nwant:\n%s\ndiagram:\n%s", joinLines(got), joinLines(want), out)
+			}
+		})
+	}
+}
@@ -0,0 +1,527 @@
+// internal/tracer/impact.go
+package tracer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// RootKind names a kind of entry point that impact analysis reports.
+type RootKind string
+
+const (
+	RootMain     RootKind = "main"     // The main function of a command.
+	RootExported RootKind = "exported" // An exported function, or an exported method of an exported type, outside package main.
+	RootHTTP     RootKind = "http"     // A function or closure with the signature of an http.HandlerFunc.
+)
+
+// DefaultRootKinds are the entry points reported when none are configured.
+var DefaultRootKinds = []RootKind{RootMain, RootExported, RootHTTP}
+
+// LineRange is an inclusive range of changed lines in a file.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// ChangedFunc is a function whose declaration overlaps changed lines.
+type ChangedFunc struct {
+	Name  string      `json:"name"`
+	File  string      `json:"file"`
+	Lines []LineRange `json:"lines"`
+}
+
+// ChangedDecl is a package-level variable, constant or type whose declaration
+// overlaps changed lines.
+type ChangedDecl struct {
+	Name   string      `json:"name"`
+	File   string      `json:"file"`
+	Lines  []LineRange `json:"lines"`
+	UsedBy []string    `json:"used_by"` // Functions that reference it in their signature or body.
+}
+
+// EntryPoint is a root whose call tree reaches changed code.
+type EntryPoint struct {
+	Name    string   `json:"name"`
+	Kind    RootKind `json:"kind"`
+	Reaches []string `json:"reaches"` // Changed functions and declarations reached from this entry point.
+}
+
+// ImpactReport lists the functions changed since a base revision and the
+// functions and entry points that call them, directly or indirectly.
+type ImpactReport struct {
+	Base        string        `json:"base"`
+	Changed     []ChangedFunc `json:"changed"`
+	Decls       []ChangedDecl `json:"changed_decls"`
+	Affected    []string      `json:"affected"` // Every function that transitively calls changed code or uses a changed declaration.
+	EntryPoints []EntryPoint  `json:"entry_points"`
+	Truncated   string        `json:"truncated,omitempty"` // Why the caller search stopped before completion, if it did.
+}
+
+// String formats the report as a plain-text summary.
+func (r *ImpactReport) String() string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Impact analysis against: %s\n", r.Base))
+	if r.Truncated != "" {
+		out.WriteString(fmt.Sprintf("Partial result: %s\n", r.Truncated))
+	}
+
+	out.WriteString("\nChanged Functions:\n")
+	if len(r.Changed) == 0 {
+		out.WriteString("- None\n")
+	}
+	for _, fn := range r.Changed {
+		out.WriteString(fmt.Sprintf("- %s (%s: lines %s)\n", fn.Name, fn.File, formatLineRanges(fn.Lines)))
+	}
+
+	out.WriteString("\nChanged Declarations:\n")
+	if len(r.Decls) == 0 {
+		out.WriteString("- None\n")
+	}
+	for _, decl := range r.Decls {
+		usedBy := "no function"
+		if len(decl.UsedBy) > 0 {
+			usedBy = strings.Join(decl.UsedBy, ", ")
+		}
+		out.WriteString(fmt.Sprintf("- %s (%s: lines %s) used by %s\n", decl.Name, decl.File, formatLineRanges(decl.Lines), usedBy))
+	}
+
+	out.WriteString("\nAffected Entry Points:\n")
+	if len(r.EntryPoints) == 0 {
+		out.WriteString("- None\n")
+	}
+	for _, entry := range r.EntryPoints {
+		out.WriteString(fmt.Sprintf("- %s [%s] reaches %s\n", entry.Name, entry.Kind, strings.Join(entry.Reaches, ", ")))
+	}
+
+	out.WriteString("\nAffected Functions:\n")
+	if len(r.Affected) == 0 {
+		out.WriteString("- None\n")
+	}
+	for _, name := range r.Affected {
+		out.WriteString(fmt.Sprintf("- %s\n", name))
+	}
+	return out.String()
+}
+
+// formatLineRanges formats line ranges as e.g. "3, 10-12".
+func formatLineRanges(ranges []LineRange) string {
+	var lines []string
+	for _, lr := range ranges {
+		if lr.Start == lr.End {
+			lines = append(lines, strconv.Itoa(lr.Start))
+		} else {
+			lines = append(lines, fmt.Sprintf("%d-%d", lr.Start, lr.End))
+		}
+	}
+	return strings.Join(lines, ", ")
+}
+
+// ChangedLines runs git in the project to find the Go lines changed since the
+// merge base of base and HEAD, including uncommitted changes and untracked
+// files that are not ignored, which count as changed throughout. Files are
+// keyed by absolute path. Deletions are attributed to the line following them.
+// Cancelling ctx kills a running git command.
+func ChangedLines(ctx context.Context, projectPath, base string) (map[string][]LineRange, error) {
+	// base is passed to git as a revision and must not be taken for an option.
+	if base == "" || strings.HasPrefix(base, "-") {
+		return nil, fmt.Errorf("invalid base revision '%s'", base)
+	}
+	root, err := runGit(ctx, projectPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := runGit(ctx, projectPath, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	// Explicit prefixes override diff.noprefix and diff.mnemonicPrefix, which
+	// parseDiff could not tell apart from file names.
+	diff, err := runGit(ctx, projectPath, "diff", "--unified=0", "--no-color", "--no-ext-diff", "--no-renames",
+		"--src-prefix=a/", "--dst-prefix=b/", strings.TrimSpace(mergeBase), "--", "*.go")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := runGit(ctx, projectPath, "ls-files", "--others", "--exclude-standard", "--full-name", "-z", "--", "*.go")
+	if err != nil {
+		return nil, err
+	}
+	root = strings.TrimSpace(root)
+	changes, err := parseDiff(root, diff)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(untracked, "\x00") {
+		if name == "" {
+			continue
+		}
+		file := filepath.Join(root, filepath.FromSlash(name))
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		lines := bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			lines++
+		}
+		if lines > 0 {
+			changes[file] = []LineRange{{Start: 1, End: lines}}
+		}
+	}
+	return changes, nil
+}
+
+// runGit runs a git command in dir and returns its standard output.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// parseDiff extracts the changed line ranges of the new file versions from a
+// zero-context unified diff with the default a/ and b/ prefixes.
+func parseDiff(root, diff string) (map[string][]LineRange, error) {
+	changes := make(map[string][]LineRange)
+	file := ""
+	// Lines of the current hunk still to come, so that removed and added
+	// lines starting with "---" or "+++" are not taken for file headers.
+	oldLeft, newLeft := 0, 0
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case oldLeft > 0 && strings.HasPrefix(line, "-"):
+			oldLeft--
+		case newLeft > 0 && strings.HasPrefix(line, "+"):
+			newLeft--
+		case strings.HasPrefix(line, "+++ "):
+			file = ""
+			if name, ok := diffPath(line[len("+++ "):]); ok {
+				file = filepath.Join(root, filepath.FromSlash(name))
+			}
+		case strings.HasPrefix(line, "@@ "):
+			// @@ -old[,count] +new[,count] @@
+			fields := strings.Fields(line)
+			if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+				return nil, fmt.Errorf("malformed hunk header: %s", line)
+			}
+			_, oldCount, err := hunkRange(fields[1][1:])
+			if err != nil {
+				return nil, fmt.Errorf("malformed hunk header: %s", line)
+			}
+			first, n, err := hunkRange(fields[2][1:])
+			if err != nil {
+				return nil, fmt.Errorf("malformed hunk header: %s", line)
+			}
+			oldLeft, newLeft = oldCount, n
+			if file == "" {
+				continue
+			}
+			if n == 0 {
+				// A pure deletion after line first.
+				changes[file] = append(changes[file], LineRange{Start: first + 1, End: first + 1})
+			} else {
+				changes[file] = append(changes[file], LineRange{Start: first, End: first + n - 1})
+			}
+		}
+	}
+	return changes, scanner.Err()
+}
+
+// hunkRange parses the "start[,count]" half of a hunk header.
+func hunkRange(field string) (start, count int, err error) {
+	startText, countText, found := strings.Cut(field, ",")
+	if start, err = strconv.Atoi(startText); err != nil {
+		return 0, 0, err
+	}
+	count = 1
+	if found {
+		if count, err = strconv.Atoi(countText); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, count, nil
+}
+
+// diffPath returns the path named in the "+++" header of a file diff, without
+// its b/ prefix. Git quotes paths with unusual characters in C style and ends
+// paths containing spaces with a tab. It reports false for /dev/null, the new
+// version of a deleted file.
+func diffPath(name string) (string, bool) {
+	name = strings.TrimSuffix(name, "\t")
+	if strings.HasPrefix(name, `"`) {
+		unquoted, err := strconv.Unquote(name)
+		if err != nil {
+			return "", false
+		}
+		name = unquoted
+	}
+	return strings.CutPrefix(name, "b/")
+}
+
+// rootKinds classifies a function declaration or function literal.
+func rootKinds(target AnalysisTarget) []RootKind {
+	var kinds []RootKind
+	var sig *types.Signature
+	if target.Lit != nil {
+		sig, _ = target.Pkg.TypesInfo.TypeOf(target.Lit).(*types.Signature)
+	} else if fnObj, ok := target.Pkg.TypesInfo.ObjectOf(target.Fn.Name).(*types.Func); ok {
+		sig, _ = fnObj.Type().(*types.Signature)
+		switch {
+		case target.Pkg.Name == "main" && target.Fn.Recv == nil && target.Fn.Name.Name == "main":
+			kinds = append(kinds, RootMain)
+		case target.Pkg.Name != "main" && ast.IsExported(target.Fn.Name.Name):
+			recv := receiverTypeName(target.Fn)
+			if recv == "" || ast.IsExported(recv) {
+				kinds = append(kinds, RootExported)
+			}
+		}
+	}
+	if sig != nil && isHTTPHandlerSignature(sig) {
+		kinds = append(kinds, RootHTTP)
+	}
+	return kinds
+}
+
+// isHTTPHandlerSignature reports whether sig is func(http.ResponseWriter, *http.Request).
+func isHTTPHandlerSignature(sig *types.Signature) bool {
+	params := sig.Params()
+	if params.Len() != 2 || sig.Results().Len() != 0 {
+		return false
+	}
+	isNetHTTP := func(t types.Type, name string) bool {
+		named, ok := t.(*types.Named)
+		return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "net/http" && named.Obj().Name() == name
+	}
+	ptr, ok := params.At(1).Type().(*types.Pointer)
+	return isNetHTTP(params.At(0).Type(), "ResponseWriter") && ok && isNetHTTP(ptr.Elem(), "Request")
+}
+
+// AnalyzeImpact maps changed line ranges to the project functions enclosing
+// them and to the package-level variables, constants and types declared on
+// them, then traces the callers of those functions and of the functions using
+// those declarations in reverse. It reports every affected function and the
+// entry points of the given kinds that reach changed code.
+func AnalyzeImpact(base string, changes map[string][]LineRange, roots []RootKind, pkgs []*packages.Package, opts Options) (*ImpactReport, error) {
+	if len(roots) == 0 {
+		roots = DefaultRootKinds
+	}
+	wantRoot := make(map[RootKind]bool)
+	for _, kind := range roots {
+		wantRoot[kind] = true
+	}
+	canonicalChanges := make(map[string][]LineRange)
+	for file, ranges := range changes {
+		canonicalChanges[canonicalPath(file)] = ranges
+	}
+	overlapping := func(ranges []LineRange, start, end int) []LineRange {
+		var lines []LineRange
+		for _, lr := range ranges {
+			if lr.Start <= end && lr.End >= start {
+				lines = append(lines, lr)
+			}
+		}
+		return lines
+	}
+
+	projectPackages := make(map[string]bool)
+	for _, p := range pkgs {
+		projectPackages[p.PkgPath] = true
+	}
+	report := &ImpactReport{Base: base, Changed: []ChangedFunc{}, Decls: []ChangedDecl{}, Affected: []string{}, EntryPoints: []EntryPoint{}}
+
+	// Changed declarations are collected first, as they may be used by
+	// functions of any package.
+	changedDecls := make(map[types.Object]int)
+	for _, p := range pkgs {
+		if p.TypesInfo == nil {
+			continue
+		}
+		for _, fileAST := range p.Syntax {
+			fileName := p.Fset.Position(fileAST.Pos()).Filename
+			ranges := canonicalChanges[canonicalPath(fileName)]
+			if len(ranges) == 0 {
+				continue
+			}
+			for _, decl := range fileAST.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok == token.IMPORT {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					lines := overlapping(ranges, p.Fset.Position(spec.Pos()).Line, p.Fset.Position(spec.End()).Line)
+					if len(lines) == 0 {
+						continue
+					}
+					var names []*ast.Ident
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						names = spec.Names
+					case *ast.TypeSpec:
+						names = []*ast.Ident{spec.Name}
+					}
+					for _, name := range names {
+						obj := p.TypesInfo.Defs[name]
+						if obj == nil || name.Name == "_" {
+							continue
+						}
+						changedDecls[obj] = len(report.Decls)
+						report.Decls = append(report.Decls, ChangedDecl{Name: p.PkgPath + "." + name.Name, File: fileName, Lines: lines, UsedBy: []string{}})
+					}
+				}
+			}
+		}
+	}
+
+	kindsByFunc := make(map[string][]RootKind)
+	for _, p := range pkgs {
+		if p.TypesInfo == nil {
+			continue
+		}
+		for _, fileAST := range p.Syntax {
+			fileName := p.Fset.Position(fileAST.Pos()).Filename
+			ranges := canonicalChanges[canonicalPath(fileName)]
+			for _, decl := range fileAST.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				walkFuncDecl(p, fn, projectPackages, func(target AnalysisTarget, key string, collector *resultCollector) {
+					kindsByFunc[key] = rootKinds(target)
+					seen := make(map[types.Object]bool)
+					for _, obj := range declReferences(target, collector) {
+						if i, ok := changedDecls[obj]; ok && !seen[obj] {
+							seen[obj] = true
+							report.Decls[i].UsedBy = append(report.Decls[i].UsedBy, key)
+						}
+					}
+				})
+				lines := overlapping(ranges, p.Fset.Position(fn.Pos()).Line, p.Fset.Position(fn.End()).Line)
+				if len(lines) == 0 {
+					continue
+				}
+				key, err := targetKey(AnalysisTarget{Pkg: p, Fn: fn})
+				if err != nil {
+					continue
+				}
+				report.Changed = append(report.Changed, ChangedFunc{Name: key, File: fileName, Lines: lines})
+			}
+		}
+	}
+
+	callerIndex, stopped := buildCallerIndex(pkgs, opts)
+	report.Truncated = truncation(stopped)
+	// A changed declaration is traced like a changed function called by every
+	// function that uses it.
+	for _, decl := range report.Decls {
+		sort.Strings(decl.UsedBy)
+		callerIndex[decl.Name] = decl.UsedBy
+	}
+	var changedNames []string
+	for _, changed := range report.Changed {
+		changedNames = append(changedNames, changed.Name)
+	}
+	for _, decl := range report.Decls {
+		changedNames = append(changedNames, decl.Name)
+	}
+	affected := make(map[string]bool)
+	reaches := make(map[string][]string)
+	for _, changed := range changedNames {
+		queue := []string{changed}
+		visited := map[string]bool{changed: true}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			reaches[current] = append(reaches[current], changed)
+			for _, caller := range callerIndex[current] {
+				if visited[caller] {
+					continue
+				}
+				visited[caller] = true
+				affected[caller] = true
+				queue = append(queue, caller)
+			}
+		}
+	}
+
+	for name := range affected {
+		report.Affected = append(report.Affected, name)
+	}
+	sort.Strings(report.Affected)
+	for name, changedNames := range reaches {
+		for _, kind := range kindsByFunc[name] {
+			if wantRoot[kind] {
+				sort.Strings(changedNames)
+				report.EntryPoints = append(report.EntryPoints, EntryPoint{Name: name, Kind: kind, Reaches: changedNames})
+				break
+			}
+		}
+	}
+	sort.Slice(report.EntryPoints, func(i, j int) bool { return report.EntryPoints[i].Name < report.EntryPoints[j].Name })
+	sort.Slice(report.Changed, func(i, j int) bool { return report.Changed[i].Name < report.Changed[j].Name })
+	sort.Slice(report.Decls, func(i, j int) bool { return report.Decls[i].Name < report.Decls[j].Name })
+	return report, nil
+}
+
+// declReferences returns the package-level variables, constants and types a
+// function or function literal refers to, in its body or its signature.
+func declReferences(target AnalysisTarget, collector *resultCollector) []types.Object {
+	refs := append(append([]types.Object(nil), collector.ReferencedGlobals...), collector.ReferencedTypes...)
+	var signature []ast.Node
+	if target.Lit != nil {
+		signature = []ast.Node{target.Lit.Type}
+	} else {
+		signature = []ast.Node{target.Fn.Type}
+		if target.Fn.Recv != nil {
+			signature = append(signature, target.Fn.Recv)
+		}
+	}
+	for _, node := range signature {
+		ast.Inspect(node, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				if obj := target.Pkg.TypesInfo.Uses[ident]; obj != nil {
+					refs = append(refs, obj)
+				}
+			}
+			return true
+		})
+	}
+	return refs
+}
+
+// ParseRootKinds splits a comma-separated list of root kinds, rejecting unknown ones.
+func ParseRootKinds(list string) ([]RootKind, error) {
+	var kinds []RootKind
+	for _, name := range strings.Split(list, ",") {
+		kind := RootKind(strings.TrimSpace(name))
+		switch kind {
+		case "":
+			continue
+		case RootMain, RootExported, RootHTTP:
+			kinds = append(kinds, kind)
+		default:
+			return nil, fmt.Errorf("unknown root kind '%s'; expected main, exported or http", kind)
+		}
+	}
+	return kinds, nil
+}
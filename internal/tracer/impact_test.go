@@ -0,0 +1,171 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDiff(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	tests := []struct {
+		name    string
+		diff    string
+		want    map[string][]LineRange
+		wantErr bool
+	}{
+		{
+			name: "additions and changes",
+			diff: "diff --git a/svc/svc.go b/svc/svc.go\n" +
+				"--- a/svc/svc.go\n" +
+				"+++ b/svc/svc.go\n" +
+				"@@ -10,0 +11,3 @@ func A() {\n" +
+				"+\tx()\n+\ty()\n+\tz()\n" +
+				"@@ -20 +23 @@ func B() {\n" +
+				"-\told()\n+\tnew()\n",
+			want: map[string][]LineRange{
+				filepath.Join(root, "svc", "svc.go"): {{Start: 11, End: 13}, {Start: 23, End: 23}},
+			},
+		},
+		{
+			name: "pure deletion",
+			diff: "--- a/a.go\n+++ b/a.go\n@@ -5,2 +4,0 @@\n-\tx()\n-\ty()\n",
+			want: map[string][]LineRange{filepath.Join(root, "a.go"): {{Start: 5, End: 5}}},
+		},
+		{
+			name: "deleted file",
+			diff: "--- a/gone.go\n+++ /dev/null\n@@ -1,3 +0,0 @@\n-package p\n-\n-func F() {}\n",
+			want: map[string][]LineRange{},
+		},
+		{
+			name: "path with spaces",
+			diff: "--- a/odd name.go\t\n+++ b/odd name.go\t\n@@ -0,0 +1 @@\n+package p\n",
+			want: map[string][]LineRange{filepath.Join(root, "odd name.go"): {{Start: 1, End: 1}}},
+		},
+		{
+			name: "quoted path",
+			diff: "--- /dev/null\n+++ \"b/caf\\303\\251.go\"\n@@ -0,0 +1,2 @@\n+package p\n+\n",
+			want: map[string][]LineRange{filepath.Join(root, "café.go"): {{Start: 1, End: 2}}},
+		},
+		{
+			name: "hunk lines that look like headers",
+			diff: "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n--- old comment\n+++ new comment\n@@ -9 +9 @@\n-a\n+b\n",
+			want: map[string][]LineRange{filepath.Join(root, "a.go"): {{Start: 1, End: 1}, {Start: 9, End: 9}}},
+		},
+		{
+			name:    "malformed hunk",
+			diff:    "--- a/a.go\n+++ b/a.go\n@@ -1 +x @@\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDiff(root, tt.diff)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDiff error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDiff = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangedLinesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ChangedLines(ctx, t.TempDir(), "main"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ChangedLines with a cancelled context: err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestAnalyzeImpactDecls(t *testing.T) {
+	pkgs := loadFixture(t, "impact")
+	file, err := filepath.Abs(filepath.Join("testdata", "impact", "impact.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Lines 4, 8 and 12 declare Limit, a field of Config and hits.
+	changes := map[string][]LineRange{file: {{Start: 4, End: 4}, {Start: 8, End: 8}, {Start: 12, End: 12}}}
+	report, err := AnalyzeImpact("main", changes, nil, pkgs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decls []string
+	for _, decl := range report.Decls {
+		decls = append(decls, fmt.Sprintf("%s used by %v", decl.Name, decl.UsedBy))
+	}
+	wantDecls := []string{
+		"example.com/impact.Config used by [example.com/impact.Apply]",
+		"example.com/impact.Limit used by [example.com/impact.Check]",
+		"example.com/impact.hits used by [example.com/impact.Check]",
+	}
+	if !reflect.DeepEqual(decls, wantDecls) {
+		t.Errorf("changed declarations = %q, want %q", decls, wantDecls)
+	}
+	if len(report.Changed) != 0 {
+		t.Errorf("changed functions = %+v, want none", report.Changed)
+	}
+	wantAffected := []string{"example.com/impact.Apply", "example.com/impact.Check", "example.com/impact.Run"}
+	if !reflect.DeepEqual(report.Affected, wantAffected) {
+		t.Errorf("affected = %q, want %q", report.Affected, wantAffected)
+	}
+	var entries []string
+	for _, entry := range report.EntryPoints {
+		entries = append(entries, fmt.Sprintf("%s reaches %v", entry.Name, entry.Reaches))
+	}
+	wantEntries := []string{
+		"example.com/impact.Apply reaches [example.com/impact.Config]",
+		"example.com/impact.Check reaches [example.com/impact.Limit example.com/impact.hits]",
+		"example.com/impact.Run reaches [example.com/impact.Limit example.com/impact.hits]",
+	}
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("entry points = %q, want %q", entries, wantEntries)
+	}
+}
+
+func TestChangedLinesUntracked(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n\nfunc A() {}\n", ".gitignore": "ignored.go\n"})
+	git("init", "-q", "-b", "main")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	writeFiles(t, dir, map[string]string{
+		"a.go":       "package a\n\nfunc A() { B() }\n",
+		"sub/new.go": "package sub\n\nfunc New() {}",
+		"ignored.go": "package a\n",
+	})
+
+	changes, err := ChangedLines(context.Background(), dir, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := canonicalPath(dir)
+	got := make(map[string][]LineRange)
+	for file, ranges := range changes {
+		got[canonicalPath(file)] = ranges
+	}
+	want := map[string][]LineRange{
+		filepath.Join(root, "a.go"):          {{Start: 3, End: 3}},
+		filepath.Join(root, "sub", "new.go"): {{Start: 1, End: 3}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedLines = %v, want %v", got, want)
+	}
+}
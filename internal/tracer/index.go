@@ -0,0 +1,551 @@
+// internal/tracer/index.go
+package tracer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// IndexVersion is bumped whenever the on-disk index format changes; an index
+// with a different version is rebuilt from scratch.
+const IndexVersion = 4
+
+// Index is a persistent project index of symbols, call edges and type
+// references. It answers called-function, referenced-type and caller queries
+// without type-checking the project.
+type Index struct {
+	Version  int                      `json:"version"`
+	Project  string                   `json:"project"`
+	Packages map[string]*PackageIndex `json:"packages"` // Keyed by package path.
+}
+
+// PackageIndex holds the indexed symbols of one package.
+type PackageIndex struct {
+	Path        string        `json:"path"`
+	Name        string        `json:"name"`
+	Fingerprint string        `json:"fingerprint"` // Hash of the package's file paths, sizes and mtimes.
+	Functions   []IndexedFunc `json:"functions"`
+	Types       []IndexedType `json:"types"`
+}
+
+// IndexedFunc is a function or method declaration, or a function literal inside
+// one, with its outgoing calls and referenced types.
+type IndexedFunc struct {
+	Name     string     `json:"name"` // Full name, as returned by types.Func.FullName, or the closure name.
+	Func     string     `json:"func"` // Bare function or method name of the declaration.
+	Receiver string     `json:"receiver,omitempty"`
+	Parent   string     `json:"parent,omitempty"` // Full name of the declaring function, for function literals.
+	File     string     `json:"file"`
+	Line     int        `json:"line"`
+	Column   int        `json:"column,omitempty"` // Start column, for function literals.
+	Calls    []CallEdge `json:"calls,omitempty"`
+	Types    []string   `json:"types,omitempty"`
+}
+
+// IndexedType is a type declaration.
+type IndexedType struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// DefaultIndexPath returns where the index of a project is stored by default.
+func DefaultIndexPath(projectPath string) string {
+	return filepath.Join(projectPath, ".gct", "index.json")
+}
+
+// LoadIndex reads an index from disk. A missing, outdated or unreadable index
+// file yields an empty index for the project, so that it is rebuilt.
+func LoadIndex(path, projectPath string) (*Index, error) {
+	idx := &Index{Version: IndexVersion, Project: projectPath, Packages: make(map[string]*PackageIndex)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored Index
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return idx, nil
+	}
+	if stored.Version != IndexVersion || stored.Project != projectPath || stored.Packages == nil {
+		return idx, nil
+	}
+	return &stored, nil
+}
+
+// Save writes the index to disk, creating its directory if needed. The index
+// is written to a temporary file that then replaces the old one, so readers
+// in this or another process never see a partly written index.
+func (idx *Index) Save(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Update refreshes the index for its project. Packages are listed without
+// type-checking, and only packages whose files changed, and the packages that
+// import them directly or indirectly, are loaded and re-indexed: a change can
+// alter what the unchanged code of an importer calls, e.g. when a method
+// declared on an outer type shadows one promoted from an embedded type.
+// It returns the paths of the packages that were re-indexed.
+// Cancelling ctx stops the update, leaving the index as it was.
+func (idx *Index) Update(ctx context.Context) ([]string, error) {
+	listCfg := &packages.Config{Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports, Dir: idx.Project, Context: ctx}
+	listed, err := packages.Load(listCfg, "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	projectPackages := make(map[string]bool)
+	fingerprints := make(map[string]string)
+	importers := make(map[string][]string)
+	var changed []string
+	for _, p := range listed {
+		projectPackages[p.PkgPath] = true
+		fingerprint, err := fingerprintFiles(p.GoFiles)
+		if err != nil {
+			return nil, err
+		}
+		fingerprints[p.PkgPath] = fingerprint
+		if existing, ok := idx.Packages[p.PkgPath]; !ok || existing.Fingerprint != fingerprint {
+			changed = append(changed, p.PkgPath)
+		}
+		for _, imported := range p.Imports {
+			importers[imported.PkgPath] = append(importers[imported.PkgPath], p.PkgPath)
+		}
+	}
+	changed = withImporters(changed, importers)
+	for path := range idx.Packages {
+		if !projectPackages[path] {
+			delete(idx.Packages, path)
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	cfg := &packages.Config{Mode: packages.LoadSyntax | packages.LoadTypes | packages.LoadFiles, Dir: idx.Project, Context: ctx}
+	pkgs, err := packages.Load(cfg, changed...)
+	if err != nil {
+		return nil, err
+	}
+	var updated []string
+	for _, p := range pkgs {
+		if p.TypesInfo == nil || !projectPackages[p.PkgPath] {
+			continue
+		}
+		pkgIndex := indexPackage(p, projectPackages)
+		pkgIndex.Fingerprint = fingerprints[p.PkgPath]
+		idx.Packages[p.PkgPath] = pkgIndex
+		updated = append(updated, p.PkgPath)
+	}
+	return updated, nil
+}
+
+// withImporters adds to a list of packages every package that imports one of
+// them, directly or indirectly, and returns the list sorted.
+func withImporters(pkgPaths []string, importers map[string][]string) []string {
+	included := make(map[string]bool)
+	queue := append([]string(nil), pkgPaths...)
+	for len(queue) > 0 {
+		pkgPath := queue[0]
+		queue = queue[1:]
+		if included[pkgPath] {
+			continue
+		}
+		included[pkgPath] = true
+		queue = append(queue, importers[pkgPath]...)
+	}
+	all := make([]string, 0, len(included))
+	for pkgPath := range included {
+		all = append(all, pkgPath)
+	}
+	sort.Strings(all)
+	return all
+}
+
+// fingerprintFiles hashes the path, size and modification time of each file.
+func fingerprintFiles(files []string) (string, error) {
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+	hash := sha256.New()
+	for _, file := range sorted {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// indexPackage records every function and type declared in a type-checked package.
+func indexPackage(p *packages.Package, projectPackages map[string]bool) *PackageIndex {
+	pkgIndex := &PackageIndex{Path: p.PkgPath, Name: p.Name}
+	for _, fileAST := range p.Syntax {
+		for _, decl := range fileAST.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			fnObj, ok := p.TypesInfo.ObjectOf(fn.Name).(*types.Func)
+			if !ok {
+				continue
+			}
+			if fn.Body == nil {
+				position := p.Fset.Position(fn.Name.Pos())
+				pkgIndex.Functions = append(pkgIndex.Functions, IndexedFunc{
+					Name:     fnObj.FullName(),
+					Func:     fn.Name.Name,
+					Receiver: receiverTypeName(fn),
+					File:     position.Filename,
+					Line:     position.Line,
+				})
+				continue
+			}
+			walkFuncDecl(p, fn, projectPackages, func(target AnalysisTarget, key string, collector *resultCollector) {
+				position := p.Fset.Position(fn.Name.Pos())
+				entry := IndexedFunc{
+					Name:     key,
+					Func:     fn.Name.Name,
+					Receiver: receiverTypeName(fn),
+				}
+				if target.Lit != nil {
+					entry.Parent = fnObj.FullName()
+					position = p.Fset.Position(target.Lit.Pos())
+				}
+				entry.File = position.Filename
+				entry.Line = position.Line
+				if target.Lit != nil {
+					entry.Column = position.Column
+				}
+
+				for _, call := range collector.Calls {
+					entry.Calls = append(entry.Calls, CallEdge{
+						Caller:   entry.Name,
+						Callee:   call.Func.FullName(),
+						Position: p.Fset.Position(call.Pos).String(),
+						Kind:     call.Kind,
+						TypeArgs: call.TypeArgs,
+					})
+				}
+				for _, closure := range collector.Closures {
+					closureKey, err := targetKey(AnalysisTarget{Pkg: p, Fn: fn, Lit: closure.Lit})
+					if err != nil {
+						continue
+					}
+					entry.Calls = append(entry.Calls, CallEdge{
+						Caller:   entry.Name,
+						Callee:   closureKey,
+						Position: p.Fset.Position(closure.Lit.Pos()).String(),
+						Kind:     closure.Kind,
+					})
+				}
+				seen := make(map[string]bool)
+				for _, typeObj := range collector.ReferencedTypes {
+					typeKey := fmt.Sprintf("%s.%s", typeObj.Pkg().Path(), typeObj.Name())
+					if !seen[typeKey] {
+						seen[typeKey] = true
+						entry.Types = append(entry.Types, typeKey)
+					}
+				}
+				pkgIndex.Functions = append(pkgIndex.Functions, entry)
+			})
+		}
+	}
+
+	scope := p.Types.Scope()
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		position := p.Fset.Position(typeName.Pos())
+		pkgIndex.Types = append(pkgIndex.Types, IndexedType{
+			Name: fmt.Sprintf("%s.%s", p.PkgPath, name),
+			File: position.Filename,
+			Line: position.Line,
+		})
+	}
+	return pkgIndex
+}
+
+// packagePaths returns the paths of the indexed packages in sorted order.
+func (idx *Index) packagePaths() []string {
+	paths := make([]string, 0, len(idx.Packages))
+	for path := range idx.Packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// functions returns all indexed functions keyed by full name.
+func (idx *Index) functions() map[string]*IndexedFunc {
+	funcs := make(map[string]*IndexedFunc)
+	for _, pkgIndex := range idx.Packages {
+		for i := range pkgIndex.Functions {
+			funcs[pkgIndex.Functions[i].Name] = &pkgIndex.Functions[i]
+		}
+	}
+	return funcs
+}
+
+// positions returns the declaration position of every indexed function and
+// type, keyed by full name. Discovery order is left for the caller to fill in.
+func (idx *Index) positions() map[string]resultPosition {
+	positions := make(map[string]resultPosition)
+	for _, pkgIndex := range idx.Packages {
+		for _, fn := range pkgIndex.Functions {
+			positions[fn.Name] = resultPosition{Package: pkgIndex.Path, File: fn.File, Line: fn.Line, Column: fn.Column}
+		}
+		for _, typ := range pkgIndex.Types {
+			positions[typ.Name] = resultPosition{Package: pkgIndex.Path, File: typ.File, Line: typ.Line}
+		}
+	}
+	return positions
+}
+
+// FindFunc locates an indexed function by name, in the given file if one is
+// given and across the whole project otherwise. funcName accepts the same
+// qualified forms as FindTarget and FindSymbol. Function literals are never
+// matched; use FindClosure to locate one.
+func (idx *Index) FindFunc(filePath, funcName string) (*IndexedFunc, error) {
+	sel, err := parseFuncSelector(funcName, func(path string) bool {
+		_, ok := idx.Packages[path]
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+	wantFile := ""
+	if filePath != "" {
+		wantFile = canonicalPath(filePath)
+	}
+	// Many functions share a file; resolve each file's path only once.
+	canonicalFiles := make(map[string]string)
+	inFile := func(file string) bool {
+		if file == filePath {
+			return true
+		}
+		canonical, ok := canonicalFiles[file]
+		if !ok {
+			canonical = canonicalPath(file)
+			canonicalFiles[file] = canonical
+		}
+		return canonical == wantFile
+	}
+
+	var candidates []*IndexedFunc
+	for _, pkgIndex := range idx.Packages {
+		for i := range pkgIndex.Functions {
+			fn := &pkgIndex.Functions[i]
+			if fn.Parent != "" {
+				continue
+			}
+			if wantFile != "" && !inFile(fn.File) {
+				continue
+			}
+			if sel.matchParts(pkgIndex.Path, pkgIndex.Name, fn.Receiver, fn.Func) {
+				candidates = append(candidates, fn)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("function '%s' not found in the index", funcName)
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.Name
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("function '%s' is ambiguous; qualify it with its package path or receiver type, candidates: %s",
+		funcName, strings.Join(names, ", "))
+}
+
+// FindClosure locates the indexed function literal declared in parent that
+// starts on the given line. When several literals start on that line, the
+// outermost one is returned.
+func (idx *Index) FindClosure(parent *IndexedFunc, line int) (*IndexedFunc, error) {
+	var found *IndexedFunc
+	for _, pkgIndex := range idx.Packages {
+		for i := range pkgIndex.Functions {
+			fn := &pkgIndex.Functions[i]
+			if fn.Parent != parent.Name || fn.Line != line {
+				continue
+			}
+			if found == nil || fn.Column < found.Column {
+				found = fn
+			}
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no function literal starts on line %d of '%s'", line, parent.Name)
+	}
+	return found, nil
+}
+
+// indexWalk is the result of a breadth-first walk over the indexed call edges.
+type indexWalk struct {
+	CalledFuncs []string
+	Types       []string
+	Edges       []CallEdge
+	Order       resultOrder
+}
+
+// walk follows call edges from root the same way performRecursiveAnalysis
+// follows the AST: functions up to depth are expanded, their callees recorded.
+// Function literals are expanded at the depth of the function declaring them.
+func (idx *Index) walk(root string, depth int) *indexWalk {
+	funcs := idx.functions()
+	positions := idx.positions()
+	result := &indexWalk{Order: make(resultOrder)}
+	record := func(name string, depth int) {
+		position := positions[name]
+		result.Order.add(name, position.Package, token.Position{Filename: position.File, Line: position.Line, Column: position.Column}, depth)
+	}
+	type indexTask struct {
+		Name  string
+		Depth int
+	}
+	tasks := []indexTask{{Name: root, Depth: 0}}
+	processed := make(map[string]bool)
+	seenFuncs := make(map[string]bool)
+	seenTypes := make(map[string]bool)
+
+	for len(tasks) > 0 {
+		current := tasks[0]
+		tasks = tasks[1:]
+		if processed[current.Name] {
+			continue
+		}
+		processed[current.Name] = true
+		fn, ok := funcs[current.Name]
+		if !ok {
+			continue
+		}
+		for _, edge := range fn.Calls {
+			edge.Depth = current.Depth
+			result.Edges = append(result.Edges, edge)
+			if seenFuncs[edge.Callee] {
+				continue
+			}
+			seenFuncs[edge.Callee] = true
+			result.CalledFuncs = append(result.CalledFuncs, edge.Callee)
+			record(edge.Callee, current.Depth+1)
+			if callee, ok := funcs[edge.Callee]; ok && callee.Parent != "" {
+				tasks = append(tasks, indexTask{Name: edge.Callee, Depth: current.Depth})
+			} else if current.Depth < depth {
+				tasks = append(tasks, indexTask{Name: edge.Callee, Depth: current.Depth + 1})
+			}
+		}
+		for _, typeName := range fn.Types {
+			if !seenTypes[typeName] {
+				seenTypes[typeName] = true
+				result.Types = append(result.Types, typeName)
+				record(typeName, current.Depth)
+			}
+		}
+	}
+	return result
+}
+
+// CalledFuncs lists the functions and methods called by root, with optional
+// recursion, in the given order.
+func (idx *Index) CalledFuncs(root string, depth int, order OrderMode) []string {
+	result := idx.walk(root, depth)
+	return result.Order.sort(result.CalledFuncs, order)
+}
+
+// ReferencedTypes lists the types referenced by root, with optional recursion,
+// in the given order.
+func (idx *Index) ReferencedTypes(root string, depth int, order OrderMode) []string {
+	result := idx.walk(root, depth)
+	return result.Order.sort(result.Types, order)
+}
+
+// CallGraph returns the call edges reachable from root, with optional recursion.
+func (idx *Index) CallGraph(root string, depth int) *CallGraph {
+	return &CallGraph{Root: root, Edges: idx.walk(root, depth).Edges}
+}
+
+// Callers lists the functions that call root. A depth of 0 returns only the
+// direct callers; each additional level adds the callers of those callers.
+func (idx *Index) Callers(root string, depth int) []string {
+	callers := make(map[string][]string)
+	for _, path := range idx.packagePaths() {
+		for _, fn := range idx.Packages[path].Functions {
+			seen := make(map[string]bool)
+			for _, edge := range fn.Calls {
+				if !seen[edge.Callee] {
+					seen[edge.Callee] = true
+					callers[edge.Callee] = append(callers[edge.Callee], fn.Name)
+				}
+			}
+		}
+	}
+
+	type callerTask struct {
+		Name  string
+		Depth int
+	}
+	queue := []callerTask{{Name: root, Depth: 0}}
+	processed := map[string]bool{root: true}
+	var callerNames []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, caller := range callers[current.Name] {
+			if processed[caller] {
+				continue
+			}
+			processed[caller] = true
+			callerNames = append(callerNames, caller)
+			if current.Depth < depth {
+				queue = append(queue, callerTask{Name: caller, Depth: current.Depth + 1})
+			}
+		}
+	}
+	return callerNames
+}
@@ -0,0 +1,143 @@
+package tracer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// writeFiles creates the given files, keyed by slash-separated path, under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIndexUpdate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module example.com/shadow\n\ngo 1.24\n",
+		"a/a.go":     "package a\n\ntype Inner struct{}\n\nfunc (Inner) Close() {}\n\ntype Outer struct{ Inner }\n",
+		"b/b.go":     "package b\n\nimport \"example.com/shadow/a\"\n\nfunc Use(o *a.Outer) { o.Close() }\n",
+		"c/c.go":     "package c\n\nfunc Alone() {}\n",
+		"d/d.go":     "package d\n\nimport \"example.com/shadow/b\"\n\nfunc Top() { b.Use(nil) }\n",
+		"e/e.go":     "package e\n\nimport \"example.com/shadow/c\"\n\nfunc Other() { c.Alone() }\n",
+		"a/extra.go": "package a\n",
+	})
+	indexPath := DefaultIndexPath(dir)
+	ctx := context.Background()
+
+	idx, err := LoadIndex(indexPath, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, err := idx.Update(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := []string{"example.com/shadow/a", "example.com/shadow/b", "example.com/shadow/c", "example.com/shadow/d", "example.com/shadow/e"}
+	if !reflect.DeepEqual(updated, all) {
+		t.Fatalf("first update re-indexed %v, want %v", updated, all)
+	}
+	if got := idx.CalledFuncs("example.com/shadow/b.Use", 0, OrderPosition); !reflect.DeepEqual(got, []string{"(example.com/shadow/a.Inner).Close"}) {
+		t.Fatalf("Use calls %v before the change", got)
+	}
+	if err := idx.Save(indexPath); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err = LoadIndex(indexPath, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated, err := idx.Update(ctx); err != nil || len(updated) != 0 {
+		t.Fatalf("update without changes re-indexed %v (err %v)", updated, err)
+	}
+
+	// Outer now declares Close itself, shadowing the promoted Inner.Close:
+	// the unchanged packages importing a, directly or not, are re-indexed.
+	writeFiles(t, dir, map[string]string{"a/extra.go": "package a\n\nfunc (*Outer) Close() {}\n"})
+	updated, err = idx.Update(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"example.com/shadow/a", "example.com/shadow/b", "example.com/shadow/d"}
+	if !reflect.DeepEqual(updated, want) {
+		t.Fatalf("update after the change re-indexed %v, want %v", updated, want)
+	}
+	if got := idx.CalledFuncs("example.com/shadow/b.Use", 0, OrderPosition); !reflect.DeepEqual(got, []string{"(*example.com/shadow/a.Outer).Close"}) {
+		t.Errorf("Use calls %v after the change", got)
+	}
+}
+
+func TestLoadIndexCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	indexPath := DefaultIndexPath(dir)
+	writeFiles(t, dir, map[string]string{".gct/index.json": `{"version": 3, "packages": {`})
+	idx, err := LoadIndex(indexPath, dir)
+	if err != nil {
+		t.Fatalf("LoadIndex of a corrupt index failed: %v", err)
+	}
+	if idx.Project != dir || len(idx.Packages) != 0 {
+		t.Errorf("LoadIndex of a corrupt index = %+v, want an empty index", idx)
+	}
+
+	idx.Packages["example.com/p"] = &PackageIndex{Path: "example.com/p", Name: "p"}
+	if err := idx.Save(indexPath); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(indexPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Save left %d files behind, want only the index", len(entries))
+	}
+	reloaded, err := LoadIndex(indexPath, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reloaded, idx) {
+		t.Errorf("reloaded index = %+v, want %+v", reloaded, idx)
+	}
+}
+
+// The index lists the same referenced types as a full trace, including the
+// constraints of the target's own type parameters.
+func TestIndexReferencedTypes(t *testing.T) {
+	pkgs := loadFixture(t, "generics")
+	dir, err := filepath.Abs(filepath.Join("testdata", "generics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := LoadIndex(filepath.Join(t.TempDir(), "index.json"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.Update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Sum", "Tag", "Use"} {
+		target := fixtureTarget(t, pkgs, "generics", "generics.go", name)
+		want, err := ExtractTypes(target, 0, pkgs, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := idx.ReferencedTypes("example.com/generics."+name, 0, OrderPosition)
+		sort.Strings(got)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("index types of %s = %q, want %q", name, got, want)
+		}
+	}
+}
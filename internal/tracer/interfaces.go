@@ -0,0 +1,218 @@
+// internal/tracer/interfaces.go
+package tracer
+
+import (
+	"fmt"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// interfaceResolver maps abstract interface methods to the concrete project
+// methods that may be invoked through them (class-hierarchy style).
+type interfaceResolver struct {
+	ProjectPackages map[string]bool
+	ConcreteTypes   []*types.Named           // All non-interface named types declared in project packages.
+	cache           map[string][]*types.Func // Implementations keyed by interface method full name.
+}
+
+// Implementation is a project type related to an interface by implementation:
+// either a type that satisfies an interface, or an interface a type satisfies.
+type Implementation struct {
+	Symbol
+	ViaPointer bool `json:"via_pointer,omitempty"` // Only the pointer to the concrete type satisfies the interface.
+}
+
+// newInterfaceResolver collects every concrete named type declared in the project packages.
+func newInterfaceResolver(pkgs []*packages.Package, projectPackages map[string]bool) *interfaceResolver {
+	r := &interfaceResolver{
+		ProjectPackages: projectPackages,
+		cache:           make(map[string][]*types.Func),
+	}
+	for _, p := range pkgs {
+		if p.Types == nil {
+			continue
+		}
+		scope := p.Types.Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+			named, ok := typeName.Type().(*types.Named)
+			if !ok || types.IsInterface(named) {
+				continue
+			}
+			if named.TypeParams().Len() > 0 {
+				if named, ok = instantiateWithTypeParams(named); !ok {
+					continue
+				}
+			}
+			r.ConcreteTypes = append(r.ConcreteTypes, named)
+		}
+	}
+	return r
+}
+
+// instantiateWithTypeParams instantiates a generic type with its own type
+// parameters, e.g. Repo[T] for Repo, whose method set then satisfies exactly
+// the interfaces that every instantiation of the type satisfies.
+func instantiateWithTypeParams(named *types.Named) (*types.Named, bool) {
+	tparams := named.TypeParams()
+	args := make([]types.Type, tparams.Len())
+	for i := range args {
+		args[i] = tparams.At(i)
+	}
+	inst, err := types.Instantiate(nil, named, args, false)
+	if err != nil {
+		return nil, false
+	}
+	instNamed, ok := inst.(*types.Named)
+	return instNamed, ok
+}
+
+// isInterfaceMethod reports whether fun is an abstract method declared on an interface.
+func isInterfaceMethod(fun *types.Func) bool {
+	sig, ok := fun.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return false
+	}
+	return types.IsInterface(sig.Recv().Type())
+}
+
+// Implementations returns the concrete project methods that satisfy the given
+// interface method. It returns nil if fun is not an interface method.
+func (r *interfaceResolver) Implementations(fun *types.Func) []*types.Func {
+	if !isInterfaceMethod(fun) {
+		return nil
+	}
+	key := fun.FullName()
+	if impls, ok := r.cache[key]; ok {
+		return impls
+	}
+
+	iface, _ := fun.Type().(*types.Signature).Recv().Type().Underlying().(*types.Interface)
+	var impls []*types.Func
+	seen := make(map[string]bool)
+	if iface != nil {
+		for _, named := range r.ConcreteTypes {
+			var recv types.Type
+			switch {
+			case types.Implements(named, iface):
+				recv = named
+			case types.Implements(types.NewPointer(named), iface):
+				recv = types.NewPointer(named)
+			default:
+				continue
+			}
+			obj, _, _ := types.LookupFieldOrMethod(recv, false, fun.Pkg(), fun.Name())
+			impl, ok := obj.(*types.Func)
+			if ok {
+				impl = impl.Origin()
+			}
+			if !ok || impl.Pkg() == nil || !r.ProjectPackages[impl.Pkg().Path()] || seen[impl.FullName()] {
+				continue
+			}
+			seen[impl.FullName()] = true
+			impls = append(impls, impl)
+		}
+	}
+	r.cache[key] = impls
+	return impls
+}
+
+// projectInterfaces returns every non-generic interface type with at least one
+// method declared in the project packages.
+func projectInterfaces(pkgs []*packages.Package) []*types.Named {
+	var ifaces []*types.Named
+	for _, p := range pkgs {
+		if p.Types == nil {
+			continue
+		}
+		scope := p.Types.Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+			named, ok := typeName.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			if iface, ok := named.Underlying().(*types.Interface); ok && iface.NumMethods() > 0 {
+				ifaces = append(ifaces, named)
+			}
+		}
+	}
+	return ifaces
+}
+
+// newImplementation describes a related type, with or without its source.
+func newImplementation(named *types.Named, viaPointer bool, typePkgMap map[*types.Package]*packages.Package, withSource bool) Implementation {
+	sym := newTypeSymbol(typePkgMap[named.Obj().Pkg()], named.Obj())
+	if !withSource {
+		sym.Source = ""
+	}
+	return Implementation{Symbol: sym, ViaPointer: viaPointer}
+}
+
+// FindImplementations lists every concrete project type that satisfies the
+// given interface, through a value or a pointer receiver. A generic type is
+// listed if it satisfies the interface whatever its type arguments; one that
+// only does for particular type arguments, e.g. Cache[string], is not.
+func FindImplementations(iface *types.TypeName, pkgs []*packages.Package, withSource bool) ([]Implementation, error) {
+	ifaceType, ok := iface.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("type '%s' is not an interface", iface.Name())
+	}
+
+	projectPackages := make(map[string]bool)
+	typePkgMap := make(map[*types.Package]*packages.Package)
+	for _, p := range pkgs {
+		projectPackages[p.PkgPath] = true
+		if p.Types != nil {
+			typePkgMap[p.Types] = p
+		}
+	}
+
+	impls := []Implementation{}
+	for _, named := range newInterfaceResolver(pkgs, projectPackages).ConcreteTypes {
+		switch {
+		case types.Implements(named, ifaceType):
+			impls = append(impls, newImplementation(named, false, typePkgMap, withSource))
+		case types.Implements(types.NewPointer(named), ifaceType):
+			impls = append(impls, newImplementation(named, true, typePkgMap, withSource))
+		}
+	}
+	return impls, nil
+}
+
+// FindInterfaces lists every project interface with at least one method that
+// the given concrete type satisfies, through a value or a pointer receiver.
+func FindInterfaces(concrete *types.TypeName, pkgs []*packages.Package, withSource bool) ([]Implementation, error) {
+	if types.IsInterface(concrete.Type()) {
+		return nil, fmt.Errorf("type '%s' is an interface, not a concrete type", concrete.Name())
+	}
+	if named, ok := concrete.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("type '%s' is generic; uninstantiated generic types are not supported", concrete.Name())
+	}
+
+	typePkgMap := make(map[*types.Package]*packages.Package)
+	for _, p := range pkgs {
+		if p.Types != nil {
+			typePkgMap[p.Types] = p
+		}
+	}
+
+	ifaces := []Implementation{}
+	for _, named := range projectInterfaces(pkgs) {
+		ifaceType := named.Underlying().(*types.Interface)
+		switch {
+		case types.Implements(concrete.Type(), ifaceType):
+			ifaces = append(ifaces, newImplementation(named, false, typePkgMap, withSource))
+		case types.Implements(types.NewPointer(concrete.Type()), ifaceType):
+			ifaces = append(ifaces, newImplementation(named, true, typePkgMap, withSource))
+		}
+	}
+	return ifaces, nil
+}
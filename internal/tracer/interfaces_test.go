@@ -0,0 +1,158 @@
+package tracer
+
+import (
+	"go/types"
+	"reflect"
+	"sort"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestResolveInterfaces(t *testing.T) {
+	pkgs := loadFixture(t, "interfaces")
+	lookup := fixtureTarget(t, pkgs, "interfaces", "interfaces.go", "Lookup")
+
+	tests := []struct {
+		name  string
+		opts  Options
+		depth int
+		want  []string
+	}{
+		{"interface method only", Options{}, 1, []string{
+			"(example.com/interfaces.Store).Get",
+		}},
+		{"resolved", Options{ResolveInterfaces: true}, 0, []string{
+			"(*example.com/interfaces.cache[T]).Get",
+			"(*example.com/interfaces.diskStore).Get",
+			"(example.com/interfaces.Store).Get",
+			"(example.com/interfaces.memStore).Get",
+		}},
+		{"traced into implementations", Options{ResolveInterfaces: true}, 1, []string{
+			"(*example.com/interfaces.cache[T]).Get",
+			"(*example.com/interfaces.diskStore).Get",
+			"(example.com/interfaces.Store).Get",
+			"(example.com/interfaces.memStore).Get",
+			"example.com/interfaces.normalize",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractCalledFuncs(lookup, tt.depth, pkgs, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractCalledFuncs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindCallersThroughInterfaces(t *testing.T) {
+	pkgs := loadFixture(t, "interfaces")
+	get := fixtureTarget(t, pkgs, "interfaces", "interfaces.go", "normalize")
+
+	got, err := FindCallers(get, 1, pkgs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"(example.com/interfaces.memStore).Get"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindCallers() = %q, want %q", got, want)
+	}
+	got, err = FindCallers(get, 1, pkgs, Options{ResolveInterfaces: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"(example.com/interfaces.memStore).Get", "example.com/interfaces.Lookup"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindCallers() with resolved interfaces = %q, want %q", got, want)
+	}
+}
+
+// fixtureType looks up a type declared at package level in a fixture.
+func fixtureType(t *testing.T, pkgs []*packages.Package, pkgPath, name string) *types.TypeName {
+	t.Helper()
+	for _, p := range pkgs {
+		if p.PkgPath != pkgPath {
+			continue
+		}
+		if typeName, ok := p.Types.Scope().Lookup(name).(*types.TypeName); ok {
+			return typeName
+		}
+	}
+	t.Fatalf("type %s.%s not found", pkgPath, name)
+	return nil
+}
+
+// implementationNames lists related types by name, marking those that only
+// qualify through a pointer.
+func implementationNames(impls []Implementation) []string {
+	names := []string{}
+	for _, impl := range impls {
+		name := impl.Name
+		if impl.ViaPointer {
+			name = "*" + name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestFindImplementations(t *testing.T) {
+	pkgs := loadFixture(t, "implementations")
+	const pkg = "example.com/implementations"
+
+	impls, err := FindImplementations(fixtureType(t, pkgs, pkg, "Store"), pkgs, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cache only satisfies Store as Cache[string] and is left out.
+	if got, want := implementationNames(impls), []string{"*" + pkg + ".Repo", "*" + pkg + ".diskStore", pkg + ".memStore"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindImplementations(Store) = %q, want %q", got, want)
+	}
+	for _, impl := range impls {
+		if impl.Source != "" || impl.StartLine == 0 {
+			t.Errorf("%s: source %q, line %d; want the position without the source", impl.Name, impl.Source, impl.StartLine)
+		}
+	}
+
+	impls, err = FindImplementations(fixtureType(t, pkgs, pkg, "Closer"), pkgs, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(impls) != 1 || impls[0].Name != pkg+".memStore" || impls[0].Source != "// memStore implements Store and Closer with value receivers.\ntype memStore struct{}" {
+		t.Errorf("FindImplementations(Closer) = %+v", impls)
+	}
+
+	if _, err := FindImplementations(fixtureType(t, pkgs, pkg, "memStore"), pkgs, false); err == nil {
+		t.Error("FindImplementations accepted a concrete type")
+	}
+}
+
+func TestFindInterfaces(t *testing.T) {
+	pkgs := loadFixture(t, "implementations")
+	const pkg = "example.com/implementations"
+
+	tests := []struct {
+		concrete string
+		want     []string
+	}{
+		// Interfaces without methods are left out.
+		{"memStore", []string{pkg + ".Closer", pkg + ".Store"}},
+		{"diskStore", []string{"*" + pkg + ".Store"}},
+		{"readOnly", []string{}},
+	}
+	for _, tt := range tests {
+		ifaces, err := FindInterfaces(fixtureType(t, pkgs, pkg, tt.concrete), pkgs, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := implementationNames(ifaces); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindInterfaces(%s) = %q, want %q", tt.concrete, got, tt.want)
+		}
+	}
+	if _, err := FindInterfaces(fixtureType(t, pkgs, pkg, "Store"), pkgs, false); err == nil {
+		t.Error("FindInterfaces accepted an interface")
+	}
+}
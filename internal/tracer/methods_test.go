@@ -0,0 +1,53 @@
+package tracer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypeMethods(t *testing.T) {
+	pkgs := loadFixture(t, "methods")
+	open := fixtureTarget(t, pkgs, "methods", "methods.go", "Open")
+
+	tests := []struct {
+		mode MethodMode
+		want map[string][]string // Method name and source by type.
+	}{
+		{MethodsNone, map[string][]string{
+			"example.com/methods.Account": nil,
+			"example.com/methods.Reader":  nil,
+		}},
+		// Promoted methods (Base.Hello) and interface methods are left out.
+		{MethodsSignatures, map[string][]string{
+			"example.com/methods.Account": {
+				"(example.com/methods.Account).Balance", "func (a Account) Balance() int",
+				"(*example.com/methods.Account).Deposit", "func (a *Account) Deposit(n int)",
+			},
+			"example.com/methods.Reader": nil,
+		}},
+		{MethodsFull, map[string][]string{
+			"example.com/methods.Account": {
+				"(example.com/methods.Account).Balance", "func (a Account) Balance() int { return a.balance }",
+				"(*example.com/methods.Account).Deposit", "func (a *Account) Deposit(n int) {\n\ta.balance += n\n}",
+			},
+			"example.com/methods.Reader": nil,
+		}},
+	}
+	for _, tt := range tests {
+		symbols, err := ExtractTypeSymbols(open, 0, pkgs, Options{Methods: tt.mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string][]string)
+		for _, sym := range symbols {
+			var methods []string
+			for _, method := range sym.Methods {
+				methods = append(methods, method.Name, method.Source)
+			}
+			got[sym.Name] = methods
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("methods (mode %q) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// internal/tracer/order.go
+package tracer
+
+import (
+	"go/token"
+	"sort"
+)
+
+// resultPosition records where a collected function, type or variable is
+// declared and when and how far from the target the analysis first reached it.
+type resultPosition struct {
+	Package string
+	File    string
+	Line    int
+	Column  int
+	Seq     int // Breadth-first discovery order, starting at 0.
+	Depth   int // Calls between the target and the item, as reported in Symbol.Depth.
+}
+
+// resultOrder maps the key of every collected item to its position, so that
+// results gathered in maps can be listed in a stable order.
+type resultOrder map[string]resultPosition
+
+// add records an item the first time it is discovered. Items without a source
+// position, such as functions of dependencies loaded from export data, are
+// ordered by package and name.
+func (o resultOrder) add(key, pkgPath string, position token.Position, depth int) {
+	if _, exists := o[key]; exists {
+		return
+	}
+	o[key] = resultPosition{
+		Package: pkgPath,
+		File:    position.Filename,
+		Line:    position.Line,
+		Column:  position.Column,
+		Seq:     len(o),
+		Depth:   depth,
+	}
+}
+
+// sort orders keys in place as selected by mode and returns them. Keys that
+// were never recorded come last, by name.
+func (o resultOrder) sort(keys []string, mode OrderMode) []string {
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, aok := o[keys[i]]
+		b, bok := o[keys[j]]
+		if aok != bok {
+			return aok
+		}
+		if mode == OrderDiscovery {
+			if a.Seq != b.Seq {
+				return a.Seq < b.Seq
+			}
+			return keys[i] < keys[j]
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// orderedKeys returns the keys of a result map in the order selected by mode.
+func orderedKeys[V any](m map[string]V, order resultOrder, mode OrderMode) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return order.sort(keys, mode)
+}
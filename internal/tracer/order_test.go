@@ -0,0 +1,43 @@
+package tracer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReportOrder(t *testing.T) {
+	pkgs := loadFixture(t, "order")
+	target := fixtureTarget(t, pkgs, "order", "b.go", "Entry")
+
+	tests := []struct {
+		order     OrderMode
+		functions []string
+		types     []string
+	}{
+		// The zero value orders by position, like OrderPosition.
+		{"", []string{"helper", "first", "second"}, []string{"Config", "Result"}},
+		{OrderPosition, []string{"helper", "first", "second"}, []string{"Config", "Result"}},
+		{OrderDiscovery, []string{"second", "first", "helper"}, []string{"Result", "Config"}},
+	}
+	for _, tt := range tests {
+		report, err := BuildReport(target, "b.go", 1, pkgs, Options{Order: tt.order})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := shortNames(report.Functions); !reflect.DeepEqual(got, tt.functions) {
+			t.Errorf("order %q: functions = %q, want %q", tt.order, got, tt.functions)
+		}
+		if got := shortNames(report.Types); !reflect.DeepEqual(got, tt.types) {
+			t.Errorf("order %q: types = %q, want %q", tt.order, got, tt.types)
+		}
+	}
+}
+
+// shortNames returns the symbol names without the fixture's package path.
+func shortNames(symbols []Symbol) []string {
+	var names []string
+	for _, sym := range symbols {
+		names = append(names, sym.Name[len(sym.Package)+1:])
+	}
+	return names
+}
@@ -0,0 +1,224 @@
+// internal/tracer/path.go
+package tracer
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// maxPathSearch bounds the number of calls examined while searching for call paths.
+const maxPathSearch = 1000000
+
+// CallPath is a chain of calls leading from a source function to a destination.
+type CallPath struct {
+	Hops []CallEdge `json:"hops"`
+}
+
+// String formats the path with one hop per line.
+func (p CallPath) String() string {
+	var out strings.Builder
+	if len(p.Hops) > 0 {
+		out.WriteString(p.Hops[0].Caller + "\n")
+	}
+	for _, hop := range p.Hops {
+		out.WriteString(fmt.Sprintf("  -> %s [%s] at %s", hop.Callee, hop.Kind, hop.Position))
+		if hop.Via != "" {
+			out.WriteString(fmt.Sprintf(" via %s", hop.Via))
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// FindCallPaths returns up to k shortest call chains from one function to
+// another, each at most maxHops calls long. Paths are ordered by length and
+// visit each function at most once; when a function calls another from several
+// call sites, the first call site is used. The shortest path is found by a
+// breadth-first search over functions and the following ones by Yen's
+// algorithm. If the search gives up after maxPathSearch calls or is stopped by
+// opts.Context, the paths found so far are returned with an error wrapping
+// ErrTruncated.
+func FindCallPaths(from, to AnalysisTarget, k, maxHops int, pkgs []*packages.Package, opts Options) ([]CallPath, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("number of paths must be at least 1, got %d", k)
+	}
+	if maxHops < 1 {
+		return nil, fmt.Errorf("maximum path length must be at least 1, got %d", maxHops)
+	}
+	source, err := targetKey(from)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := targetKey(to)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := performRecursiveAnalysis(from, maxHops-1, pkgs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the first edge between each pair of functions, in discovery order.
+	search := &pathSearch{adjacency: make(map[string][]CallEdge), budget: maxPathSearch}
+	seenPairs := make(map[[2]string]bool)
+	for _, edge := range results.Edges {
+		pair := [2]string{edge.Caller, edge.Callee}
+		if seenPairs[pair] {
+			continue
+		}
+		seenPairs[pair] = true
+		search.adjacency[edge.Caller] = append(search.adjacency[edge.Caller], edge)
+	}
+
+	paths := []CallPath{}
+	first, complete := search.shortest(source, destination, maxHops, nil, nil)
+	if !complete {
+		return paths, search.limitError()
+	}
+	if first == nil {
+		return paths, results.Stopped
+	}
+	paths = append(paths, newCallPath(first))
+
+	// Yen's algorithm: every further path leaves the previous one at some
+	// function, the spur, and takes the shortest way from there that avoids
+	// the calls the paths found so far make at that point.
+	var candidates [][]CallEdge
+	for len(paths) < k {
+		if stopped := opts.stopped(); stopped != nil {
+			return paths, stopped
+		}
+		previous := paths[len(paths)-1].Hops
+		for i := range previous {
+			root := previous[:i]
+			spur := source
+			blockedNodes := make(map[string]bool)
+			if i > 0 {
+				spur = root[i-1].Callee
+				blockedNodes[source] = true
+				for _, hop := range root[:i-1] {
+					blockedNodes[hop.Callee] = true
+				}
+			}
+			blockedEdges := make(map[[2]string]bool)
+			for _, path := range paths {
+				if len(path.Hops) > i && sameCalls(path.Hops[:i], root) {
+					blockedEdges[[2]string{path.Hops[i].Caller, path.Hops[i].Callee}] = true
+				}
+			}
+			spurPath, complete := search.shortest(spur, destination, maxHops-i, blockedNodes, blockedEdges)
+			if !complete {
+				return paths, search.limitError()
+			}
+			if spurPath == nil {
+				continue
+			}
+			candidate := append(append([]CallEdge(nil), root...), spurPath...)
+			if !containsPath(paths, candidates, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		// The shortest candidate wins; at equal length, the one found first.
+		best := 0
+		for i, candidate := range candidates {
+			if len(candidate) < len(candidates[best]) {
+				best = i
+			}
+		}
+		paths = append(paths, newCallPath(candidates[best]))
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+	return paths, results.Stopped
+}
+
+// pathSearch finds shortest paths through the call edges reached from the
+// source function.
+type pathSearch struct {
+	adjacency map[string][]CallEdge // Outgoing calls of each function.
+	budget    int                   // Calls left to examine before the search gives up.
+}
+
+// shortest returns the shortest path of at most maxHops calls from start to
+// destination that avoids the blocked functions and calls, or nil if there is
+// none. It reports false if the search budget ran out first.
+func (s *pathSearch) shortest(start, destination string, maxHops int, blockedNodes map[string]bool, blockedEdges map[[2]string]bool) ([]CallEdge, bool) {
+	predecessor := make(map[string]CallEdge)
+	reached := map[string]bool{start: true}
+	frontier := []string{start}
+	for hops := 0; hops < maxHops && len(frontier) > 0; hops++ {
+		var next []string
+		for _, fn := range frontier {
+			for _, edge := range s.adjacency[fn] {
+				if s.budget == 0 {
+					return nil, false
+				}
+				s.budget--
+				if reached[edge.Callee] || blockedNodes[edge.Callee] || blockedEdges[[2]string{edge.Caller, edge.Callee}] {
+					continue
+				}
+				reached[edge.Callee] = true
+				predecessor[edge.Callee] = edge
+				if edge.Callee == destination {
+					path := make([]CallEdge, hops+1)
+					for i, fn := hops, destination; i >= 0; i-- {
+						path[i] = predecessor[fn]
+						fn = path[i].Caller
+					}
+					return path, true
+				}
+				next = append(next, edge.Callee)
+			}
+		}
+		frontier = next
+	}
+	return nil, true
+}
+
+// limitError reports that the search gave up before it was done.
+func (s *pathSearch) limitError() error {
+	return fmt.Errorf("%w: call path search gave up after examining %d calls", ErrTruncated, maxPathSearch)
+}
+
+// newCallPath copies a chain of calls into a path, numbering its hops.
+func newCallPath(hops []CallEdge) CallPath {
+	path := CallPath{Hops: append([]CallEdge(nil), hops...)}
+	for i := range path.Hops {
+		path.Hops[i].Depth = i
+	}
+	return path
+}
+
+// sameCalls reports whether two chains make the same calls in the same order.
+func sameCalls(a, b []CallEdge) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Caller != b[i].Caller || a[i].Callee != b[i].Callee {
+			return false
+		}
+	}
+	return true
+}
+
+// containsPath reports whether a chain is among the paths found or the
+// candidates waiting to be picked.
+func containsPath(paths []CallPath, candidates [][]CallEdge, hops []CallEdge) bool {
+	for _, path := range paths {
+		if sameCalls(path.Hops, hops) {
+			return true
+		}
+	}
+	for _, candidate := range candidates {
+		if sameCalls(candidate, hops) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package tracer
+
+import (
+	"strings"
+	"testing"
+)
+
+// pathNames lists the functions a path passes through, without package paths.
+func pathNames(path CallPath) string {
+	names := []string{path.Hops[0].Caller}
+	for _, hop := range path.Hops {
+		names = append(names, hop.Callee)
+	}
+	return strings.ReplaceAll(strings.Join(names, " -> "), "example.com/paths.", "")
+}
+
+func TestFindCallPaths(t *testing.T) {
+	pkgs := loadFixture(t, "paths")
+	from, err := FindSymbol(pkgs, "example.com/paths.Start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := FindSymbol(pkgs, "example.com/paths.End")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		k       int
+		maxHops int
+		want    []string
+	}{
+		{"shortest", 1, 10, []string{"Start -> viaLeft -> End"}},
+		{"k shortest", 3, 10, []string{
+			"Start -> viaLeft -> End",
+			"Start -> viaRight -> middle -> End",
+			"Start -> viaLong -> long1 -> long2 -> End",
+		}},
+		{"more than exist", 5, 10, []string{
+			"Start -> viaLeft -> End",
+			"Start -> viaRight -> middle -> End",
+			"Start -> viaLong -> long1 -> long2 -> End",
+		}},
+		{"max hops", 3, 3, []string{
+			"Start -> viaLeft -> End",
+			"Start -> viaRight -> middle -> End",
+		}},
+		{"too short", 1, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := FindCallPaths(from, to, tt.k, tt.maxHops, pkgs, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for i, path := range paths {
+				got = append(got, pathNames(path))
+				for depth, hop := range path.Hops {
+					if hop.Depth != depth {
+						t.Errorf("path %d hop %d has depth %d", i, depth, hop.Depth)
+					}
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got paths\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+
+	for _, bad := range []struct{ k, maxHops int }{{0, 10}, {-1, 10}, {1, 0}} {
+		if _, err := FindCallPaths(from, to, bad.k, bad.maxHops, pkgs, Options{}); err == nil {
+			t.Errorf("FindCallPaths(k=%d, maxHops=%d) succeeded, want an error", bad.k, bad.maxHops)
+		}
+	}
+}
+
+func TestPathSearchBudget(t *testing.T) {
+	search := &pathSearch{adjacency: map[string][]CallEdge{
+		"a": {{Caller: "a", Callee: "b"}, {Caller: "a", Callee: "c"}},
+		"b": {{Caller: "b", Callee: "d"}},
+		"c": {{Caller: "c", Callee: "d"}},
+	}, budget: 3}
+	if path, complete := search.shortest("a", "d", 5, nil, nil); !complete || len(path) != 2 || path[0].Callee != "b" {
+		t.Fatalf("shortest = %v, %v; want a -> b -> d", path, complete)
+	}
+	if _, complete := search.shortest("a", "d", 5, nil, nil); complete {
+		t.Errorf("search with an exhausted budget reported completion")
+	}
+}
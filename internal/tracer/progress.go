@@ -0,0 +1,15 @@
+// internal/tracer/progress.go
+package tracer
+
+import "fmt"
+
+// progressInterval is how many functions, or packages when indexing callers,
+// are processed between two progress updates.
+const progressInterval = 50
+
+// progress passes a status message to opts.Progress, if set.
+func (opts Options) progress(format string, args ...any) {
+	if opts.Progress != nil {
+		opts.Progress(fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,172 @@
+// internal/tracer/render.go
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Supported report formats.
+const (
+	FormatText    = "text"
+	FormatJSON    = "json"
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+)
+
+// RenderReport renders a report in the format selected by opts.Format.
+// An empty format selects the text report; the DOT and Mermaid formats draw
+// only the call graph.
+func RenderReport(report *Report, opts Options) (string, error) {
+	switch opts.Format {
+	case "", FormatText:
+		return renderText(report, opts), nil
+	case FormatJSON:
+		return renderJSON(report)
+	case FormatDOT:
+		return renderDOT(report), nil
+	case FormatMermaid:
+		return renderMermaid(report), nil
+	default:
+		return "", fmt.Errorf("unknown report format '%s'", opts.Format)
+	}
+}
+
+// renderJSON serializes the report as indented JSON.
+func renderJSON(report *Report) (string, error) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// renderText renders the human-readable report.
+func renderText(report *Report, opts Options) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Analysis for Function: %s (depth=%d)\n", report.Function, report.Depth))
+	out.WriteString(fmt.Sprintf("Defined in: %s\n", report.File))
+	if report.Truncated != "" {
+		out.WriteString(fmt.Sprintf("Partial result: %s\n", report.Truncated))
+	}
+
+	if report.Snippets == SnippetsSkeleton {
+		out.WriteString("\n--- Target Function Signature ---\n")
+	} else {
+		out.WriteString("\n--- Target Function Source Code ---\n")
+	}
+	if report.Target.Source == "" {
+		out.WriteString(fmt.Sprintf("// Error getting source: %v\n", report.Target.Error))
+	} else {
+		out.WriteString(report.Target.Source + "\n")
+	}
+
+	out.WriteString("\n--- Summary of Dependencies ---\n")
+	out.WriteString("Called Functions/Methods:\n")
+	writeNameList(&out, report.Functions)
+
+	out.WriteString("\nReferenced Types:\n")
+	writeNameList(&out, report.Types)
+
+	out.WriteString("\nReferenced Variables/Constants:\n")
+	writeNameList(&out, report.Globals)
+
+	if opts.External != ExternalNone {
+		out.WriteString("\nExternal Functions/Methods:\n")
+		writeNameList(&out, report.External)
+	}
+
+	if opts.IncludeEdges {
+		out.WriteString("\nCall Edges:\n")
+		if len(report.Edges) > 0 {
+			for _, edge := range report.Edges {
+				out.WriteString(fmt.Sprintf("- %s\n", edge))
+			}
+		} else {
+			out.WriteString("- None\n")
+		}
+	}
+
+	if report.Snippets == SnippetsFull {
+		out.WriteString("\n--- Code Snippets of Dependencies ---\n")
+	} else {
+		out.WriteString("\n--- Code Snippets of Dependencies (signatures only) ---\n")
+	}
+	writeSnippets(&out, report.Functions, true)
+	writeSnippets(&out, report.Types, false)
+	writeSnippets(&out, report.Globals, false)
+	writeSnippets(&out, report.External, false)
+
+	if report.Notice != "" {
+		out.WriteString("\n--- Omitted Snippets ---\n")
+		omitted := 0
+		for _, list := range []struct {
+			symbols   []Symbol
+			fetchable bool
+		}{{report.Functions, true}, {report.Types, false}, {report.Globals, false}, {report.External, false}} {
+			for _, sym := range list.symbols {
+				if sym.Trimmed == TrimOmitted {
+					out.WriteString(omittedLine(sym, list.fetchable))
+					omitted++
+				}
+			}
+		}
+		if omitted == 0 {
+			out.WriteString("- None\n")
+		}
+		out.WriteString("\n" + report.Notice + "\n")
+	}
+	return out.String()
+}
+
+// writeNameList writes one bullet per symbol name, or "- None" if there are none.
+func writeNameList(out *strings.Builder, symbols []Symbol) {
+	if len(symbols) == 0 {
+		out.WriteString("- None\n")
+		return
+	}
+	for _, sym := range symbols {
+		out.WriteString(fmt.Sprintf("- %s\n", sym.Name))
+	}
+}
+
+// writeSnippets writes the source of every symbol whose source is available.
+// Project functions trimmed to their signature are marked with how to fetch
+// them in full if fetchable is set.
+func writeSnippets(out *strings.Builder, symbols []Symbol, fetchable bool) {
+	for _, sym := range symbols {
+		if sym.Source == "" {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("\n// Source for: %s\n", sym.Name))
+		if sym.File != "" {
+			out.WriteString(fmt.Sprintf("// Defined in: %s\n", sym.File))
+		} else {
+			out.WriteString(fmt.Sprintf("// Defined in package: %s\n", sym.Package))
+		}
+		if sym.Trimmed == TrimSignature {
+			out.WriteString(trimmedNote(sym, fetchable))
+		}
+		out.WriteString("// --------------------------------------------------\n")
+		out.WriteString(sym.Source + "\n")
+		writeMethods(out, sym)
+	}
+}
+
+// writeMethods writes the attached methods of a type after its declaration.
+func writeMethods(out *strings.Builder, sym Symbol) {
+	if len(sym.Methods) == 0 {
+		return
+	}
+	out.WriteString(fmt.Sprintf("\n// Methods of: %s\n", sym.Name))
+	for i, method := range sym.Methods {
+		if method.Source == "" {
+			continue
+		}
+		if i > 0 && strings.Contains(method.Source, "\n") {
+			out.WriteString("\n")
+		}
+		out.WriteString(method.Source + "\n")
+	}
+}
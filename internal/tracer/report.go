@@ -0,0 +1,286 @@
+// internal/tracer/report.go
+package tracer
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Symbol describes a function, method, type, variable or constant declaration included in a report.
+type Symbol struct {
+	Name      string   `json:"name"`
+	Package   string   `json:"package"`
+	Receiver  string   `json:"receiver,omitempty"` // Receiver type of a method, e.g. "*Store".
+	File      string   `json:"file,omitempty"`
+	StartLine int      `json:"start_line,omitempty"`
+	EndLine   int      `json:"end_line,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	Error     string   `json:"error,omitempty"`   // Why the source could not be retrieved, if it could not.
+	Methods   []Symbol `json:"methods,omitempty"` // Declared methods of a type, when requested.
+	Depth     int      `json:"depth,omitempty"`   // Calls between the target and the point where the symbol was reached.
+	Trimmed   string   `json:"trimmed,omitempty"` // TrimSignature or TrimOmitted if the source was cut to fit the size budget.
+}
+
+// Report is the structured result of a full dependency analysis. Every output
+// format is rendered from this model.
+type Report struct {
+	Function  string      `json:"function"` // Short target name, e.g. "(*Store).Save" for methods.
+	File      string      `json:"file"`     // Target file as requested.
+	Depth     int         `json:"depth"`
+	Target    Symbol      `json:"target"`
+	Functions []Symbol    `json:"functions"`
+	Types     []Symbol    `json:"types"`
+	Globals   []Symbol    `json:"globals"`  // Package-level variables and constants.
+	External  []Symbol    `json:"external"` // Standard library and dependency functions, when recorded.
+	Edges     []CallEdge  `json:"edges"`
+	Snippets  SnippetMode `json:"snippets,omitempty"`  // How much of each function's source is shown.
+	Notice    string      `json:"notice,omitempty"`    // How to fetch the sources trimmed to fit the size budget.
+	Truncated string      `json:"truncated,omitempty"` // Why the analysis stopped before completion, if it did.
+}
+
+// BuildReport performs the recursive code analysis and assembles the structured report.
+func BuildReport(initialTarget AnalysisTarget, initialFile string, depth int, pkgs []*packages.Package, opts Options) (*Report, error) {
+	results, err := performRecursiveAnalysis(initialTarget, depth, pkgs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	typePkgMap := make(map[*types.Package]*packages.Package)
+	for _, p := range pkgs {
+		if p.Types != nil {
+			typePkgMap[p.Types] = p
+		}
+	}
+
+	report := &Report{
+		Function:  displayName(initialTarget),
+		File:      initialFile,
+		Depth:     depth,
+		Functions: []Symbol{},
+		Types:     []Symbol{},
+		Globals:   []Symbol{},
+		External:  []Symbol{},
+		Edges:     results.Edges,
+		Snippets:  opts.Snippets,
+		Truncated: truncation(results.Stopped),
+	}
+	if report.Edges == nil {
+		report.Edges = []CallEdge{}
+	}
+	if initialTarget.Lit != nil {
+		if key, err := targetKey(initialTarget); err == nil {
+			report.Target = newClosureSymbol(initialTarget, key)
+		}
+	} else if fnObj, ok := initialTarget.Pkg.TypesInfo.ObjectOf(initialTarget.Fn.Name).(*types.Func); ok {
+		report.Target = newFuncSymbol(initialTarget.Pkg, fnObj)
+	}
+
+	opts.progress("Collecting sources of %d functions, %d types and %d variables",
+		len(results.CalledFuncs)+len(results.Closures)+len(results.ExternalFuncs), len(results.ReferencedTypes), len(results.ReferencedGlobals))
+	// Functions and closures are listed together, so their keys are ordered together.
+	funcKeys := orderedKeys(results.CalledFuncs, results.Order, opts.Order)
+	for key := range results.Closures {
+		funcKeys = append(funcKeys, key)
+	}
+	for _, key := range results.Order.sort(funcKeys, opts.Order) {
+		if closure, ok := results.Closures[key]; ok {
+			sym := newClosureSymbol(closure, key)
+			sym.Depth = results.Order[key].Depth
+			report.Functions = append(report.Functions, sym)
+			continue
+		}
+		fun := results.CalledFuncs[key]
+		sym := newFuncSymbol(typePkgMap[fun.Pkg()], fun)
+		sym.Depth = results.Order[key].Depth
+		report.Functions = append(report.Functions, sym)
+	}
+	// Load the packages of all external functions at once.
+	externalKeys := orderedKeys(results.ExternalFuncs, results.Order, opts.Order)
+	if len(externalKeys) > 0 {
+		var externalPkgs []string
+		for _, key := range externalKeys {
+			externalPkgs = append(externalPkgs, results.ExternalFuncs[key].Pkg().Path())
+		}
+		results.Dependencies.Load(externalPkgs)
+	}
+	for _, key := range externalKeys {
+		sym := newExternalSymbol(results.Dependencies, results.ExternalFuncs[key], results.TracedExternals[key])
+		sym.Depth = results.Order[key].Depth
+		report.External = append(report.External, sym)
+	}
+	for _, key := range orderedKeys(results.ReferencedTypes, results.Order, opts.Order) {
+		info := results.ReferencedTypes[key]
+		sym := newTypeSymbol(typePkgMap[info.Definition.Pkg()], info.Definition)
+		sym.Methods = typeMethods(info.Definition, typePkgMap, opts.Methods)
+		sym.Depth = results.Order[key].Depth
+		report.Types = append(report.Types, sym)
+	}
+	for _, key := range orderedKeys(results.ReferencedGlobals, results.Order, opts.Order) {
+		obj := results.ReferencedGlobals[key]
+		sym := newValueSymbol(typePkgMap[obj.Pkg()], obj)
+		sym.Depth = results.Order[key].Depth
+		report.Globals = append(report.Globals, sym)
+	}
+
+	if opts.StripComments {
+		stripSymbolComments(&report.Target)
+		for _, list := range [][]Symbol{report.Functions, report.Types, report.Globals, report.External} {
+			for i := range list {
+				stripSymbolComments(&list[i])
+			}
+		}
+	}
+	if opts.Snippets == SnippetsSkeleton {
+		reduceToSkeleton(&report.Target)
+	}
+	if opts.Snippets == SnippetsSkeleton || opts.Snippets == SnippetsHybrid {
+		for _, list := range [][]Symbol{report.Functions, report.Types, report.External} {
+			for i := range list {
+				reduceToSkeleton(&list[i])
+			}
+		}
+	}
+	return report, nil
+}
+
+// stripSymbolComments removes the comments from the source of a symbol and of
+// the methods attached to it.
+func stripSymbolComments(sym *Symbol) {
+	sym.Source = stripComments(sym.Source)
+	for i := range sym.Methods {
+		sym.Methods[i].Source = stripComments(sym.Methods[i].Source)
+	}
+}
+
+// reduceToSkeleton replaces the source of a function, and of the methods
+// attached to a type, with its doc comment and signature. Type declarations
+// keep their fields.
+func reduceToSkeleton(sym *Symbol) {
+	if signature, ok := signatureOf(sym.Source); ok {
+		sym.Source = signature
+	}
+	for i := range sym.Methods {
+		if signature, ok := signatureOf(sym.Methods[i].Source); ok {
+			sym.Methods[i].Source = signature
+		}
+	}
+}
+
+// newFuncSymbol describes a function or method declared in pkg. The position and
+// source are left empty when pkg is nil.
+func newFuncSymbol(pkg *packages.Package, fun *types.Func) Symbol {
+	sym := funcSymbolName(fun)
+	if pkg == nil {
+		return sym
+	}
+	setSymbolPosition(&sym, pkg, fun.Pos())
+	if node := findFuncDeclAt(pkg, fun.Pos()); node != nil {
+		sym.StartLine = pkg.Fset.Position(node.Pos()).Line
+		sym.EndLine = pkg.Fset.Position(node.End()).Line
+	}
+	snippet, err := getFuncSourceSnippet(pkg, fun.Pos())
+	if err != nil {
+		sym.Error = err.Error()
+	} else {
+		sym.Source = snippet
+	}
+	return sym
+}
+
+// funcSymbolName describes a function by its name, package and receiver only.
+func funcSymbolName(fun *types.Func) Symbol {
+	sym := Symbol{Name: fun.FullName(), Package: fun.Pkg().Path()}
+	if sig, ok := fun.Type().(*types.Signature); ok && sig.Recv() != nil {
+		sym.Receiver = types.TypeString(sig.Recv().Type(), func(*types.Package) string { return "" })
+	}
+	return sym
+}
+
+// newTypeSymbol describes a type declared in pkg. The position and source are
+// left empty when pkg is nil.
+func newTypeSymbol(pkg *packages.Package, obj types.Object) Symbol {
+	sym := Symbol{Name: obj.Pkg().Path() + "." + obj.Name(), Package: obj.Pkg().Path()}
+	if pkg == nil {
+		return sym
+	}
+	setSymbolPosition(&sym, pkg, obj.Pos())
+	if node := findTypeDeclAt(pkg, obj.Pos()); node != nil {
+		sym.StartLine = pkg.Fset.Position(node.Pos()).Line
+		sym.EndLine = pkg.Fset.Position(node.End()).Line
+	}
+	snippet, err := getTypeSourceSnippet(pkg, obj.Pos())
+	if err != nil {
+		sym.Error = err.Error()
+	} else {
+		sym.Source = snippet
+	}
+	return sym
+}
+
+// newValueSymbol describes a package-level variable or constant declared in pkg.
+// The position and source are left empty when pkg is nil.
+func newValueSymbol(pkg *packages.Package, obj types.Object) Symbol {
+	sym := Symbol{Name: obj.Pkg().Path() + "." + obj.Name(), Package: obj.Pkg().Path()}
+	if pkg == nil {
+		return sym
+	}
+	setSymbolPosition(&sym, pkg, obj.Pos())
+	if node := findValueDeclAt(pkg, obj.Pos()); node != nil {
+		sym.StartLine = pkg.Fset.Position(node.Pos()).Line
+		sym.EndLine = pkg.Fset.Position(node.End()).Line
+	}
+	snippet, err := getValueSourceSnippet(pkg, obj.Pos())
+	if err != nil {
+		sym.Error = err.Error()
+	} else {
+		sym.Source = snippet
+	}
+	return sym
+}
+
+// typeMethods describes the methods declared directly on a project type, using
+// its pointer method set so that both value and pointer receivers are included.
+// Promoted methods of embedded types are left out. The source of each method is
+// its signature or its full declaration, depending on mode.
+func typeMethods(obj types.Object, typePkgMap map[*types.Package]*packages.Package, mode MethodMode) []Symbol {
+	if mode == MethodsNone {
+		return nil
+	}
+	typeName, ok := obj.(*types.TypeName)
+	if !ok || typeName.IsAlias() || types.IsInterface(typeName.Type()) {
+		return nil
+	}
+
+	var methods []Symbol
+	methodSet := types.NewMethodSet(types.NewPointer(typeName.Type()))
+	for i := 0; i < methodSet.Len(); i++ {
+		selection := methodSet.At(i)
+		fun, ok := selection.Obj().(*types.Func)
+		if !ok || len(selection.Index()) != 1 {
+			continue
+		}
+		defPkg := typePkgMap[fun.Pkg()]
+		sym := newFuncSymbol(defPkg, fun)
+		if mode == MethodsSignatures && defPkg != nil {
+			sym.Source = ""
+			signature, err := getFuncSignatureSnippet(defPkg, fun.Pos(), false)
+			if err != nil {
+				sym.Error = err.Error()
+			} else {
+				sym.Source = signature
+			}
+		}
+		methods = append(methods, sym)
+	}
+	return methods
+}
+
+// setSymbolPosition fills in the file and a single-line range for the object at pos.
+func setSymbolPosition(sym *Symbol, pkg *packages.Package, pos token.Pos) {
+	position := pkg.Fset.Position(pos)
+	sym.File = position.Filename
+	sym.StartLine = position.Line
+	sym.EndLine = position.Line
+}
@@ -0,0 +1,65 @@
+package tracer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRenderJSON(t *testing.T) {
+	pkgs := loadFixture(t, "report")
+	move := fixtureTarget(t, pkgs, "report", "report.go", "Move")
+
+	out, err := Analyze(move, "report.go", 1, pkgs, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	for _, sym := range append([]*Symbol{&got.Target}, symbolRefs(got.Functions, got.Types)...) {
+		sym.File = filepath.Base(sym.File)
+	}
+	for i := range got.Edges {
+		got.Edges[i].Position = filepath.Base(got.Edges[i].Position)
+	}
+
+	want := Report{
+		Function: "Move",
+		File:     "report.go",
+		Depth:    1,
+		Target: Symbol{
+			Name: "example.com/report.Move", Package: "example.com/report", File: "report.go", StartLine: 11, EndLine: 13,
+			Source: "func Move(p Point) Point {\n\treturn scale(p)\n}",
+		},
+		Functions: []Symbol{{
+			Name: "example.com/report.scale", Package: "example.com/report", File: "report.go", StartLine: 7, EndLine: 9, Depth: 1,
+			Source: "func scale(p Point) Point {\n\treturn Point{p.X * 2, p.Y * 2}\n}",
+		}},
+		Types: []Symbol{{
+			Name: "example.com/report.Point", Package: "example.com/report", File: "report.go", StartLine: 3, EndLine: 5, Depth: 1,
+			Source: "type Point struct {\n\tX, Y int\n}",
+		}},
+		Globals:  []Symbol{},
+		External: []Symbol{},
+		Edges: []CallEdge{{
+			Caller: "example.com/report.Move", Callee: "example.com/report.scale", Position: "report.go:12:9", Depth: 0, Kind: CallDirect,
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("report:\n%+v\nwant:\n%+v", got, want)
+	}
+}
+
+// symbolRefs returns pointers to the symbols of the given lists.
+func symbolRefs(lists ...[]Symbol) []*Symbol {
+	var refs []*Symbol
+	for _, list := range lists {
+		for i := range list {
+			refs = append(refs, &list[i])
+		}
+	}
+	return refs
+}
@@ -0,0 +1,61 @@
+package tracer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnippetModes(t *testing.T) {
+	pkgs := loadFixture(t, "snippets")
+	target := fixtureTarget(t, pkgs, "snippets", "snippets.go", "Entry")
+
+	const (
+		entryFull      = "// Entry doubles n up to a limit of ten.\nfunc Entry(n int) int {\n\tc := Config{Max: 10}\n\treturn c.Clamp(double(n))\n}"
+		entrySignature = "// Entry doubles n up to a limit of ten.\nfunc Entry(n int) int"
+		doubleFull     = "// double returns twice n.\nfunc double(n int) int {\n\treturn n * 2\n}"
+		doubleSig      = "// double returns twice n.\nfunc double(n int) int"
+		clampFull      = "// Clamp limits n to the configured maximum.\nfunc (c Config) Clamp(n int) int {\n\tif n > c.Max {\n\t\treturn c.Max\n\t}\n\treturn n\n}"
+		clampSig       = "// Clamp limits n to the configured maximum.\nfunc (c Config) Clamp(n int) int"
+		// Type declarations keep their fields in every mode.
+		config = "// Config holds the limits applied by Entry.\ntype Config struct {\n\tMax int // Upper bound.\n}"
+	)
+	tests := []struct {
+		mode      SnippetMode
+		target    string
+		functions []string
+		methods   []string
+	}{
+		{SnippetsFull, entryFull, []string{clampFull, doubleFull}, []string{clampFull}},
+		{SnippetsSkeleton, entrySignature, []string{clampSig, doubleSig}, []string{clampSig}},
+		{SnippetsHybrid, entryFull, []string{clampSig, doubleSig}, []string{clampSig}},
+	}
+	for _, tt := range tests {
+		report, err := BuildReport(target, "snippets.go", 0, pkgs, Options{Snippets: tt.mode, Methods: MethodsFull})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Target.Source != tt.target {
+			t.Errorf("mode %q: target source = %q, want %q", tt.mode, report.Target.Source, tt.target)
+		}
+		if got := symbolSources(report.Functions); !reflect.DeepEqual(got, tt.functions) {
+			t.Errorf("mode %q: function sources = %q, want %q", tt.mode, got, tt.functions)
+		}
+		if len(report.Types) != 1 {
+			t.Fatalf("mode %q: got %d types, want 1", tt.mode, len(report.Types))
+		}
+		if report.Types[0].Source != config {
+			t.Errorf("mode %q: type source = %q, want %q", tt.mode, report.Types[0].Source, config)
+		}
+		if got := symbolSources(report.Types[0].Methods); !reflect.DeepEqual(got, tt.methods) {
+			t.Errorf("mode %q: method sources = %q, want %q", tt.mode, got, tt.methods)
+		}
+	}
+}
+
+func symbolSources(symbols []Symbol) []string {
+	var sources []string
+	for _, sym := range symbols {
+		sources = append(sources, sym.Source)
+	}
+	return sources
+}
@@ -0,0 +1,190 @@
+// internal/tracer/surface.go
+package tracer
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// stdModule is the module name used for standard library packages.
+const stdModule = "std"
+
+// unknownModule groups the packages whose module could not be determined,
+// because the analysis was stopped before module information was loaded.
+const unknownModule = "unknown"
+
+// DependencySurface lists every symbol outside the project that a function
+// relies on, directly or through the project functions it calls, grouped by
+// module and package.
+type DependencySurface struct {
+	Function  string          `json:"function"`
+	Depth     int             `json:"depth"`
+	Modules   []SurfaceModule `json:"modules"`
+	Truncated string          `json:"truncated,omitempty"` // Why the trace stopped before completion, if it did.
+}
+
+// SurfaceModule groups the dependency symbols used from one module.
+type SurfaceModule struct {
+	Path     string           `json:"path"` // Module path, or "std" for the standard library.
+	Version  string           `json:"version,omitempty"`
+	Replace  string           `json:"replace,omitempty"` // Replacement from go.mod, as "path version".
+	Packages []SurfacePackage `json:"packages"`
+}
+
+// SurfacePackage lists the functions, methods and types used from one dependency package.
+type SurfacePackage struct {
+	Path      string   `json:"path"`
+	Functions []string `json:"functions,omitempty"`
+	Methods   []string `json:"methods,omitempty"` // Methods as "(Receiver).Name", e.g. "(*Client).PutObject".
+	Types     []string `json:"types,omitempty"`
+}
+
+// String formats the surface as an indented outline.
+func (s *DependencySurface) String() string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Dependency surface of: %s (depth=%d)\n", s.Function, s.Depth))
+	if s.Truncated != "" {
+		out.WriteString(fmt.Sprintf("Partial result: %s\n", s.Truncated))
+	}
+	if len(s.Modules) == 0 {
+		out.WriteString("\n- None\n")
+	}
+	for _, module := range s.Modules {
+		out.WriteString("\nModule: " + module.Path)
+		if module.Version != "" {
+			out.WriteString(" " + module.Version)
+		}
+		if module.Replace != "" {
+			out.WriteString(" => " + module.Replace)
+		}
+		out.WriteString("\n")
+		for _, pkg := range module.Packages {
+			out.WriteString(fmt.Sprintf("  Package: %s\n", pkg.Path))
+			writeSurfaceNames(&out, "Functions", pkg.Functions)
+			writeSurfaceNames(&out, "Methods", pkg.Methods)
+			writeSurfaceNames(&out, "Types", pkg.Types)
+		}
+	}
+	return out.String()
+}
+
+func writeSurfaceNames(out *strings.Builder, label string, names []string) {
+	if len(names) > 0 {
+		out.WriteString(fmt.Sprintf("    %s: %s\n", label, strings.Join(names, ", ")))
+	}
+}
+
+// BuildDependencySurface collects the dependency functions, methods and types
+// referenced by a function and the project functions it reaches up to depth.
+// Dependency code itself is only followed if opts.External is ExternalTrace;
+// opts.ExternalAllow and opts.ExternalDeny restrict the packages reported.
+func BuildDependencySurface(target AnalysisTarget, depth int, pkgs []*packages.Package, opts Options) (*DependencySurface, error) {
+	if opts.External == ExternalNone {
+		opts.External = ExternalLeaf
+	}
+	results, err := performRecursiveAnalysis(target, depth, pkgs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	byPkg := make(map[string]*SurfacePackage)
+	pkgEntry := func(pkgPath string) *SurfacePackage {
+		if entry, ok := byPkg[pkgPath]; ok {
+			return entry
+		}
+		entry := &SurfacePackage{Path: pkgPath}
+		byPkg[pkgPath] = entry
+		return entry
+	}
+	for _, fun := range results.ExternalFuncs {
+		entry := pkgEntry(fun.Pkg().Path())
+		sig, ok := fun.Type().(*types.Signature)
+		if ok && sig.Recv() != nil {
+			recv := types.TypeString(sig.Recv().Type(), func(*types.Package) string { return "" })
+			entry.Methods = append(entry.Methods, fmt.Sprintf("(%s).%s", recv, fun.Name()))
+		} else {
+			entry.Functions = append(entry.Functions, fun.Name())
+		}
+	}
+	for _, obj := range results.ExternalTypes {
+		entry := pkgEntry(obj.Pkg().Path())
+		entry.Types = append(entry.Types, obj.Name())
+	}
+
+	var pkgPaths []string
+	for pkgPath := range byPkg {
+		pkgPaths = append(pkgPaths, pkgPath)
+	}
+	sort.Strings(pkgPaths)
+	modules, err := results.Dependencies.Modules(pkgPaths)
+	if err != nil {
+		// Loading module information fails too once the context is done.
+		if results.Stopped = opts.stopped(); results.Stopped == nil {
+			return nil, err
+		}
+	}
+
+	surface := &DependencySurface{Function: displayName(target), Depth: depth, Modules: []SurfaceModule{}, Truncated: truncation(results.Stopped)}
+	moduleIndex := make(map[string]int)
+	for _, pkgPath := range pkgPaths {
+		entry := byPkg[pkgPath]
+		sort.Strings(entry.Functions)
+		sort.Strings(entry.Methods)
+		sort.Strings(entry.Types)
+
+		// Packages without a module are standard library packages, but only
+		// if module information was actually loaded.
+		module := SurfaceModule{Path: stdModule}
+		if modules == nil {
+			module = SurfaceModule{Path: unknownModule}
+		} else if m := modules[pkgPath]; m != nil {
+			module = SurfaceModule{Path: m.Path, Version: m.Version}
+			if m.Replace != nil {
+				module.Replace = strings.TrimSpace(m.Replace.Path + " " + m.Replace.Version)
+			}
+		}
+		i, ok := moduleIndex[module.Path]
+		if !ok {
+			i = len(surface.Modules)
+			moduleIndex[module.Path] = i
+			surface.Modules = append(surface.Modules, module)
+		}
+		surface.Modules[i].Packages = append(surface.Modules[i].Packages, *entry)
+	}
+	sort.SliceStable(surface.Modules, func(i, j int) bool {
+		a, b := surface.Modules[i].Path, surface.Modules[j].Path
+		if (a == stdModule) != (b == stdModule) {
+			return a == stdModule
+		}
+		if (a == unknownModule) != (b == unknownModule) {
+			return b == unknownModule
+		}
+		return a < b
+	})
+	return surface, nil
+}
+
+// Modules returns the module each of the given dependency packages belongs to,
+// as recorded in go.mod. Standard library packages have no module and are left
+// out. Packages are listed without being type-checked.
+func (d *dependencySources) Modules(pkgPaths []string) (map[string]*packages.Module, error) {
+	modules := make(map[string]*packages.Module)
+	if len(pkgPaths) == 0 {
+		return modules, nil
+	}
+	cfg := &packages.Config{Mode: packages.NeedName | packages.NeedModule, Dir: d.Dir, Context: d.Context}
+	listed, err := packages.Load(cfg, pkgPaths...)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range listed {
+		if p.Module != nil {
+			modules[p.PkgPath] = p.Module
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,307 @@
+// internal/tracer/target.go
+package tracer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// funcSelector is a parsed target name such as "Get", "UserRepo.Get",
+// "(*UserRepo).Get", "pkgpath.Func" or "pkgpath.(*UserRepo).Get".
+type funcSelector struct {
+	Pkg       string // Package path or name the function must be declared in.
+	Recv      string // Receiver type name the method must be declared on.
+	Qualifier string // An unqualified "X" in "X.Name" that may name either a package or a receiver type.
+	Name      string
+}
+
+// parseFuncSelector splits a target name into its package, receiver and function
+// parts. isPackage reports whether a path names a loaded package; it settles
+// where the package path ends when its last segment contains dots, as in
+// "gopkg.in/yaml.v3.Unmarshal", and may be nil. Type arguments of a generic
+// receiver, as in "(*List[T]).Push", are ignored.
+func parseFuncSelector(selector string, isPackage func(string) bool) (funcSelector, error) {
+	var sel funcSelector
+	selector = strings.TrimSpace(selector)
+
+	if open := strings.Index(selector, "("); open >= 0 {
+		closing := strings.Index(selector, ")")
+		if closing < open || !strings.HasPrefix(selector[closing+1:], ".") {
+			return sel, fmt.Errorf("invalid function selector '%s'", selector)
+		}
+		sel.Pkg = strings.TrimSuffix(selector[:open], ".")
+		sel.Recv = withoutTypeArgs(strings.TrimPrefix(selector[open+1:closing], "*"))
+		sel.Name = selector[closing+2:]
+		// Full names qualify the receiver instead, e.g. "(*myproj/db.Store).Save".
+		if dot := strings.LastIndex(sel.Recv, "."); dot >= 0 && sel.Pkg == "" {
+			sel.Pkg, sel.Recv = sel.Recv[:dot], sel.Recv[dot+1:]
+		}
+	} else {
+		qualifier := ""
+		sel.Name = selector
+		if dot := strings.LastIndex(selector, "."); dot >= 0 {
+			qualifier, sel.Name = selector[:dot], selector[dot+1:]
+		}
+		lastSegment := qualifier[strings.LastIndex(qualifier, "/")+1:]
+		switch {
+		case qualifier == "":
+		case strings.Contains(lastSegment, "."):
+			// "pkgpath.Type.Method": the longest prefix naming a loaded package
+			// is the package path; failing that, the first dot of the last path
+			// segment ends it.
+			split := len(qualifier) - len(lastSegment) + strings.Index(lastSegment, ".")
+			for end := len(qualifier); isPackage != nil && end > split; end = strings.LastIndex(qualifier[:end], ".") {
+				if isPackage(qualifier[:end]) {
+					split = end
+					break
+				}
+			}
+			sel.Pkg, sel.Recv = qualifier[:split], withoutTypeArgs(strings.TrimPrefix(qualifier[split:], "."))
+		case strings.Contains(qualifier, "/"):
+			sel.Pkg = qualifier
+		default:
+			sel.Qualifier = withoutTypeArgs(qualifier)
+		}
+	}
+
+	if sel.Name == "" || strings.ContainsAny(sel.Name, "().*/") {
+		return sel, fmt.Errorf("invalid function selector '%s'", selector)
+	}
+	return sel, nil
+}
+
+// withoutTypeArgs strips the type arguments from a receiver type name, e.g.
+// "List[T]" becomes "List".
+func withoutTypeArgs(name string) string {
+	if bracket := strings.Index(name, "["); bracket >= 0 {
+		return name[:bracket]
+	}
+	return name
+}
+
+// loadedPackage returns a function reporting whether a path names one of pkgs.
+func loadedPackage(pkgs []*packages.Package) func(string) bool {
+	paths := make(map[string]bool, len(pkgs))
+	for _, p := range pkgs {
+		paths[p.PkgPath] = true
+	}
+	return func(path string) bool { return paths[path] }
+}
+
+// matchesPackage reports whether q names a package by import path, path suffix or package name.
+func matchesPackage(pkgPath, pkgName, q string) bool {
+	return pkgPath == q || strings.HasSuffix(pkgPath, "/"+q) || pkgName == q
+}
+
+// receiverTypeName returns the name of a method's receiver type, or "" for plain functions.
+func receiverTypeName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// Matches reports whether the declaration fn in package p is selected.
+func (sel funcSelector) Matches(p *packages.Package, fn *ast.FuncDecl) bool {
+	return sel.matchParts(p.PkgPath, p.Name, receiverTypeName(fn), fn.Name.Name)
+}
+
+// matchParts reports whether a function with the given package, receiver type
+// name ("" for plain functions) and name is selected.
+func (sel funcSelector) matchParts(pkgPath, pkgName, recv, name string) bool {
+	if name != sel.Name {
+		return false
+	}
+	if sel.Pkg != "" && !matchesPackage(pkgPath, pkgName, sel.Pkg) {
+		return false
+	}
+	switch {
+	case sel.Recv != "":
+		return recv == sel.Recv
+	case sel.Qualifier != "":
+		return recv == sel.Qualifier || (recv == "" && matchesPackage(pkgPath, pkgName, sel.Qualifier))
+	case sel.Pkg != "":
+		return recv == ""
+	}
+	return true
+}
+
+// displayName returns the short name of a target, e.g. "(*UserRepo).Get" for methods.
+// Function literals are named after their declaring function, e.g. "Handler$29:26".
+func displayName(target AnalysisTarget) string {
+	suffix := ""
+	if target.Lit != nil {
+		suffix = closureSuffix(target)
+	}
+	fnObj, ok := target.Pkg.TypesInfo.ObjectOf(target.Fn.Name).(*types.Func)
+	if !ok {
+		return target.Fn.Name.Name + suffix
+	}
+	sig, ok := fnObj.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return fnObj.Name() + suffix
+	}
+	recv := types.TypeString(sig.Recv().Type(), func(*types.Package) string { return "" })
+	return fmt.Sprintf("(%s).%s%s", recv, fnObj.Name(), suffix)
+}
+
+// ResolveFile turns a file path given relative to the project root, or absolute,
+// into a clean absolute path.
+func ResolveFile(projectPath, filePath string) string {
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(projectPath, filePath)
+	}
+	if abs, err := filepath.Abs(filePath); err == nil {
+		filePath = abs
+	}
+	return filepath.Clean(filePath)
+}
+
+// canonicalPath resolves symlinks so that equivalent paths compare equal. Paths
+// that cannot be resolved are only cleaned.
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// findCandidates returns every function declaration selected by sel in the files accepted by inFile.
+func findCandidates(pkgs []*packages.Package, sel funcSelector, inFile func(string) bool) []AnalysisTarget {
+	var candidates []AnalysisTarget
+	for _, p := range pkgs {
+		for i, file := range p.GoFiles {
+			if i >= len(p.Syntax) || !inFile(file) {
+				continue
+			}
+			for _, decl := range p.Syntax[i].Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && sel.Matches(p, fn) {
+					candidates = append(candidates, AnalysisTarget{Pkg: p, Fn: fn})
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// FindTarget locates the target function declaration within the loaded packages.
+// funcName may be a bare name or be qualified by receiver type and/or package path,
+// e.g. "Get", "UserRepo.Get", "(*UserRepo).Get" or "pkgpath.Func". filePath is
+// compared after resolving symlinks.
+func FindTarget(pkgs []*packages.Package, filePath, funcName string) (AnalysisTarget, error) {
+	var target AnalysisTarget
+	sel, err := parseFuncSelector(funcName, loadedPackage(pkgs))
+	if err != nil {
+		return target, err
+	}
+
+	wantFile := canonicalPath(filePath)
+	candidates := findCandidates(pkgs, sel, func(file string) bool {
+		return file == filePath || canonicalPath(file) == wantFile
+	})
+
+	switch len(candidates) {
+	case 0:
+		return target, fmt.Errorf("function '%s' not found in file '%s'", funcName, filePath)
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = displayName(c)
+	}
+	return target, fmt.Errorf("function '%s' is ambiguous in file '%s'; qualify it with its receiver type, candidates: %s",
+		funcName, filePath, strings.Join(names, ", "))
+}
+
+// FindSymbol locates a function or method by symbol path across all loaded packages,
+// e.g. "myproj/internal/db.(*Store).Save", "db.Store.Save" or "db.Open".
+func FindSymbol(pkgs []*packages.Package, symbol string) (AnalysisTarget, error) {
+	var target AnalysisTarget
+	sel, err := parseFuncSelector(symbol, loadedPackage(pkgs))
+	if err != nil {
+		return target, err
+	}
+
+	candidates := findCandidates(pkgs, sel, func(string) bool { return true })
+	switch len(candidates) {
+	case 0:
+		return target, fmt.Errorf("symbol '%s' not found in the loaded packages", symbol)
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.Fn.Name.Name
+		if fnObj, ok := c.Pkg.TypesInfo.ObjectOf(c.Fn.Name).(*types.Func); ok {
+			names[i] = fnObj.FullName()
+		}
+	}
+	return target, fmt.Errorf("symbol '%s' is ambiguous; qualify it with its package path or receiver type, candidates: %s",
+		symbol, strings.Join(names, ", "))
+}
+
+// TargetName returns the short name of the target as used in reports, e.g.
+// "(*UserRepo).Get" for methods or "Handler$29:26" for function literals.
+func TargetName(target AnalysisTarget) string {
+	return displayName(target)
+}
+
+// TargetFile returns the absolute path of the file that declares the target.
+func TargetFile(target AnalysisTarget) string {
+	return target.Pkg.Fset.Position(target.Fn.Pos()).Filename
+}
+
+// FindType locates a type declared in a project package by name, e.g. "Store",
+// "db.Store" or "myproj/internal/db.Store".
+func FindType(pkgs []*packages.Package, typeName string) (*types.TypeName, error) {
+	typeName = strings.TrimPrefix(strings.TrimSpace(typeName), "*")
+	qualifier, name := "", typeName
+	if dot := strings.LastIndex(typeName, "."); dot >= 0 {
+		qualifier, name = typeName[:dot], typeName[dot+1:]
+	}
+
+	var candidates []*types.TypeName
+	for _, p := range pkgs {
+		if p.Types == nil || (qualifier != "" && !matchesPackage(p.PkgPath, p.Name, qualifier)) {
+			continue
+		}
+		if obj, ok := p.Types.Scope().Lookup(name).(*types.TypeName); ok {
+			candidates = append(candidates, obj)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("type '%s' not found in the loaded packages", typeName)
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.Pkg().Path() + "." + c.Name()
+	}
+	return nil, fmt.Errorf("type '%s' is ambiguous; qualify it with its package path, candidates: %s",
+		typeName, strings.Join(names, ", "))
+}
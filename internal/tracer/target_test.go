@@ -0,0 +1,163 @@
+package tracer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFuncSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     funcSelector
+		wantErr  bool
+	}{
+		{selector: "Get", want: funcSelector{Name: "Get"}},
+		{selector: " Get ", want: funcSelector{Name: "Get"}},
+		{selector: "UserRepo.Get", want: funcSelector{Qualifier: "UserRepo", Name: "Get"}},
+		{selector: "(*UserRepo).Get", want: funcSelector{Recv: "UserRepo", Name: "Get"}},
+		{selector: "(UserRepo).Get", want: funcSelector{Recv: "UserRepo", Name: "Get"}},
+		{selector: "example.com/app/db.Open", want: funcSelector{Pkg: "example.com/app/db", Name: "Open"}},
+		{selector: "example.com/app/db.(*UserRepo).Get", want: funcSelector{Pkg: "example.com/app/db", Recv: "UserRepo", Name: "Get"}},
+		{selector: "example.com/app/db.UserRepo.Get", want: funcSelector{Pkg: "example.com/app/db", Recv: "UserRepo", Name: "Get"}},
+		{selector: "db.(UserRepo).Get", want: funcSelector{Pkg: "db", Recv: "UserRepo", Name: "Get"}},
+		{selector: "(*example.com/app/db.UserRepo).Get", want: funcSelector{Pkg: "example.com/app/db", Recv: "UserRepo", Name: "Get"}},
+		{selector: "(*List[T]).Push", want: funcSelector{Recv: "List", Name: "Push"}},
+		{selector: "List[T].Push", want: funcSelector{Qualifier: "List", Name: "Push"}},
+		{selector: "example.com/app/db.(*Map[K, V]).Put", want: funcSelector{Pkg: "example.com/app/db", Recv: "Map", Name: "Put"}},
+		{selector: "gopkg.in/yaml.v3.Unmarshal", want: funcSelector{Pkg: "gopkg.in/yaml.v3", Name: "Unmarshal"}},
+		{selector: "gopkg.in/yaml.v3.Decoder.Decode", want: funcSelector{Pkg: "gopkg.in/yaml.v3", Recv: "Decoder", Name: "Decode"}},
+		{selector: "gopkg.in/yaml.v3.(*Decoder).Decode", want: funcSelector{Pkg: "gopkg.in/yaml.v3", Recv: "Decoder", Name: "Decode"}},
+		{selector: "", wantErr: true},
+		{selector: "UserRepo.", wantErr: true},
+		{selector: "(*UserRepo)Get", wantErr: true},
+		{selector: "Get)(", wantErr: true},
+		{selector: "(*UserRepo).(Get)", wantErr: true},
+	}
+	isPackage := func(path string) bool { return path == "gopkg.in/yaml.v3" }
+	for _, tt := range tests {
+		got, err := parseFuncSelector(tt.selector, isPackage)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFuncSelector(%q) error = %v, wantErr %v", tt.selector, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseFuncSelector(%q) = %+v, want %+v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestFindTargetSelectors(t *testing.T) {
+	pkgs := loadFixture(t, "targets")
+	file, err := filepath.Abs(filepath.Join("testdata", "targets", "targets.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		selector string
+		want     string
+		wantErr  string
+	}{
+		{selector: "UserRepo.Get", want: "(*UserRepo).Get"},
+		{selector: "(*UserRepo).Get", want: "(*UserRepo).Get"},
+		{selector: "(OrderRepo).Get", want: "(OrderRepo).Get"},
+		{selector: "targets.Get", want: "Get"},
+		{selector: "example.com/targets.Get", want: "Get"},
+		{selector: "Get", wantErr: "candidates: (*UserRepo).Get, (OrderRepo).Get, Get"},
+		{selector: "(*Missing).Get", wantErr: "not found"},
+	}
+	for _, tt := range tests {
+		target, err := FindTarget(pkgs, file, tt.selector)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("FindTarget(%q) error = %v, want it to contain %q", tt.selector, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FindTarget(%q): %v", tt.selector, err)
+			continue
+		}
+		if got := displayName(target); got != tt.want {
+			t.Errorf("FindTarget(%q) found %s, want %s", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestFindSymbol(t *testing.T) {
+	pkgs := loadFixture(t, "targets")
+	tests := []struct {
+		symbol  string
+		want    string
+		file    string
+		wantErr string
+	}{
+		{symbol: "example.com/targets.(*UserRepo).Get", want: "(*UserRepo).Get"},
+		{symbol: "targets.OrderRepo.Get", want: "(OrderRepo).Get"},
+		{symbol: "targets.Get", want: "Get"},
+		{symbol: "Get", wantErr: "candidates: (*example.com/targets.UserRepo).Get, (example.com/targets.OrderRepo).Get, example.com/targets.Get"},
+		{symbol: "other.Get", wantErr: "not found"},
+		{symbol: "example.com/targets/yaml.v3.Unmarshal", want: "Unmarshal", file: "yaml.go"},
+		{symbol: "example.com/targets/yaml.v3.Decoder.Decode", want: "(*Decoder).Decode", file: "yaml.go"},
+		{symbol: "(*example.com/targets.List[T]).Push", want: "(*List[T]).Push", file: "list.go"},
+	}
+	for _, tt := range tests {
+		target, err := FindSymbol(pkgs, tt.symbol)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("FindSymbol(%q) error = %v, want it to contain %q", tt.symbol, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FindSymbol(%q): %v", tt.symbol, err)
+			continue
+		}
+		if got := displayName(target); got != tt.want {
+			t.Errorf("FindSymbol(%q) found %s, want %s", tt.symbol, got, tt.want)
+		}
+		wantFile := "targets.go"
+		if tt.file != "" {
+			wantFile = tt.file
+		}
+		if got := filepath.Base(TargetFile(target)); got != wantFile {
+			t.Errorf("TargetFile() = %s, want %s", got, wantFile)
+		}
+	}
+}
+
+// A method candidate listed for an ambiguous name is accepted as a selector,
+// also when its receiver is generic.
+func TestFindTargetAmbiguityCandidates(t *testing.T) {
+	pkgs := loadFixture(t, "targets")
+	file, err := filepath.Abs(filepath.Join("testdata", "targets", "list.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = FindTarget(pkgs, file, "Push")
+	if err == nil {
+		t.Fatal("FindTarget(Push) is not ambiguous")
+	}
+	_, list, ok := strings.Cut(err.Error(), "candidates: ")
+	if !ok || list != "(*List[T]).Push, Push" {
+		t.Fatalf("FindTarget(Push) error = %v", err)
+	}
+	candidate, _, _ := strings.Cut(list, ", ")
+	target, err := FindTarget(pkgs, file, candidate)
+	if err != nil {
+		t.Fatalf("FindTarget(%q): %v", candidate, err)
+	}
+	if got := displayName(target); got != candidate {
+		t.Errorf("FindTarget(%q) found %s", candidate, got)
+	}
+}
+
+func TestFindTargetRelativeFile(t *testing.T) {
+	pkgs := loadFixture(t, "targets")
+	root := filepath.Join("testdata", "targets")
+	for _, file := range []string{"targets.go", "./sub/../targets.go"} {
+		if _, err := FindTarget(pkgs, ResolveFile(root, file), "UserRepo.Get"); err != nil {
+			t.Errorf("FindTarget with file %q: %v", file, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package budget
+
+// Store keeps items in insertion order.
+type Store struct {
+	items []string
+}
+
+// Entry is the target of the budget tests.
+func Entry(s *Store) int {
+	s.Add("entry")
+	return near(len(s.items))
+}
+
+// Add appends an item unless it is already stored.
+func (s *Store) Add(item string) {
+	for _, existing := range s.items {
+		if existing == item {
+			return
+		}
+	}
+	s.items = append(s.items, item)
+}
+
+// near is called by the target directly.
+func near(n int) int {
+	total := 0
+	for i := 0; i < n; i++ {
+		switch {
+		case i%15 == 0:
+			total += 15
+		case i%5 == 0:
+			total += 5
+		case i%3 == 0:
+			total += 3
+		default:
+			total += i * i
+		}
+	}
+	if total > 1000 {
+		total %= 1000
+	}
+	return far(total)
+}
+
+// far is only reached through near.
+func far(n int) int {
+	steps := 0
+	for n != 1 && steps < 1000 {
+		if n%2 == 0 {
+			n /= 2
+		} else {
+			n = 3*n + 1
+		}
+		steps++
+	}
+	switch {
+	case steps > 900:
+		return -9
+	case steps > 800:
+		return -8
+	case steps > 700:
+		return -7
+	case steps > 600:
+		return -6
+	case steps > 500:
+		return -5
+	case steps > 400:
+		return -4
+	case steps > 300:
+		return -3
+	case steps > 200:
+		return -2
+	case steps > 100:
+		return -1
+	case steps > 10:
+		return steps / 10
+	default:
+		return steps
+	}
+}
@@ -0,0 +1,17 @@
+package callers
+
+import "example.com/callers/store"
+
+func Handle() {
+	var r store.Repo
+	r.Put()
+}
+
+func Serve() { Handle() }
+
+func Main() {
+	Serve()
+	store.Save()
+}
+
+func Idle() {}
@@ -0,0 +1,16 @@
+package store
+
+// Save is the function whose callers are traced.
+func Save() {}
+
+// Repo wraps Save in a method.
+type Repo struct{}
+
+func (Repo) Put() { Save() }
+
+func (r *Repo) PutTwice() {
+	Save()
+	Save()
+}
+
+func unrelated() {}
@@ -0,0 +1,18 @@
+package closures
+
+func handle() {}
+
+func audit() {}
+
+func work() {}
+
+func Register(h func()) {}
+
+func Serve() {
+	Register(func() {
+		handle()
+		log := func() { audit() }
+		log()
+	})
+	go func() { work() }()
+}
@@ -0,0 +1,18 @@
+package comments
+
+// Point is a position on the grid.
+type Point struct {
+	X int // Column.
+	// Y is the row, counted from the top.
+	Y int
+} // Point is compared by value.
+
+// Move shifts p by one column.
+func Move(p Point) Point {
+	// Columns grow to the right.
+	p.X += 1 /* one step */
+	return Point{X: p.X, Y: p.Y}
+} // Move never fails.
+
+// Unrelated must not leak into the snippets above.
+func Unrelated() {}
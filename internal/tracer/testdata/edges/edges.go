@@ -0,0 +1,28 @@
+package edges
+
+type Runner interface {
+	Run()
+}
+
+type job struct{}
+
+func (job) Run() { step() }
+
+func step() {}
+
+func cleanup() {}
+
+func worker() {}
+
+func apply(f func()) { f() }
+
+func Entry(r Runner) {
+	step()
+	defer cleanup()
+	go worker()
+	apply(step)
+	var j job
+	run := j.Run
+	run()
+	r.Run()
+}
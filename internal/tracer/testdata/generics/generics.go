@@ -0,0 +1,41 @@
+package generics
+
+type Number interface {
+	~int | ~float64
+}
+
+type Labeler interface {
+	Label() string
+}
+
+type ID int
+
+func (ID) Label() string { return "" }
+
+func Sum[T Number](xs []T) T {
+	var s T
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+func Tag[T Labeler](v T) string { return v.Label() }
+
+type Box[T any] struct {
+	v T
+}
+
+func (b *Box[T]) Set(v T) { b.v = v }
+
+func (b Box[T]) Get() T { return identity(b.v) }
+
+func identity[T any](v T) T { return v }
+
+func Use() {
+	Sum([]int{1, 2})
+	Tag(ID(1))
+	var b Box[string]
+	b.Set("x")
+	_ = b.Get()
+}
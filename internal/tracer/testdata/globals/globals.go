@@ -0,0 +1,24 @@
+package globals
+
+const Limit = 10
+
+var counter int
+
+var (
+	name  = "worker"
+	debug bool
+)
+
+const (
+	Red = iota
+	Green
+)
+
+func helper() int { return Limit + Green }
+
+func Run() {
+	counter++
+	local := name
+	_ = local
+	helper()
+}
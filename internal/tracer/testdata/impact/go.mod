@@ -0,0 +1,3 @@
+module example.com/impact
+
+go 1.22
@@ -0,0 +1,32 @@
+package impact
+
+// Limit bounds every check.
+const Limit = 10
+
+// Config is taken by Apply.
+type Config struct {
+	Max int
+}
+
+var (
+	hits  int
+	total int
+)
+
+// Check reports whether n is within Limit.
+func Check(n int) bool {
+	hits++
+	return n < Limit
+}
+
+// Apply uses Config only in its signature.
+func Apply(c Config) int {
+	return c.Max
+}
+
+// Run is an entry point reaching Check.
+func Run() bool {
+	return Check(1)
+}
+
+func count() int { return total }
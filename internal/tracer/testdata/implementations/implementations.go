@@ -0,0 +1,42 @@
+package implementations
+
+type Store interface {
+	Get(key string) string
+	Put(key, value string)
+}
+
+type Closer interface {
+	Close()
+}
+
+type Empty interface{}
+
+// memStore implements Store and Closer with value receivers.
+type memStore struct{}
+
+func (memStore) Get(key string) string { return "" }
+func (memStore) Put(key, value string) {}
+func (memStore) Close()                {}
+
+// diskStore implements Store only through a pointer.
+type diskStore struct{}
+
+func (diskStore) Get(key string) string  { return "" }
+func (*diskStore) Put(key, value string) {}
+
+// readOnly lacks Put.
+type readOnly struct{}
+
+func (readOnly) Get(key string) string { return "" }
+
+// Repo implements Store through a pointer for every element type.
+type Repo[T any] struct{ items map[string]T }
+
+func (r *Repo[T]) Get(key string) string { return key }
+func (r *Repo[T]) Put(key, value string) {}
+
+// Cache only implements Store once instantiated as Cache[string].
+type Cache[T any] struct{}
+
+func (Cache[T]) Get(key T) T      { return key }
+func (Cache[T]) Put(key, value T) {}
@@ -0,0 +1,28 @@
+package interfaces
+
+// Store is implemented by memStore with a value receiver and by diskStore
+// and the generic cache with a pointer receiver.
+type Store interface {
+	Get(key string) string
+}
+
+type memStore struct{}
+
+func (memStore) Get(key string) string { return normalize(key) }
+
+type diskStore struct{}
+
+func (*diskStore) Get(key string) string { return "" }
+
+type cache[T any] struct{ items map[string]T }
+
+func (*cache[T]) Get(key string) string { return key }
+
+// other has a Get method with a different signature and is no Store.
+type other struct{}
+
+func (other) Get() string { return "" }
+
+func normalize(key string) string { return key }
+
+func Lookup(s Store) string { return s.Get("k") }
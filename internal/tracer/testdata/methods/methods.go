@@ -0,0 +1,26 @@
+package methods
+
+type Base struct{}
+
+func (Base) Hello() string { return "hi" }
+
+type Account struct {
+	Base
+	balance int
+}
+
+func (a Account) Balance() int { return a.balance }
+
+func (a *Account) Deposit(n int) {
+	a.balance += n
+}
+
+type Reader interface {
+	Read() string
+}
+
+func Open() {
+	var a Account
+	var r Reader
+	_, _ = a, r
+}
@@ -0,0 +1,7 @@
+package order
+
+func helper() {}
+
+type Config struct{}
+
+func first() { helper() }
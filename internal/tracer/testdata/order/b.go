@@ -0,0 +1,15 @@
+package order
+
+type Result struct{}
+
+// Entry reaches second before first, and Result before Config, so discovery
+// order differs from declaration order.
+func Entry() Config {
+	var r Result
+	second()
+	first()
+	_ = r
+	return Config{}
+}
+
+func second() {}
@@ -0,0 +1,26 @@
+package paths
+
+func Start() {
+	viaLeft()
+	viaRight()
+	viaLong()
+}
+
+func viaLeft() { End() }
+
+func viaRight() { middle() }
+
+func middle() {
+	if false {
+		viaRight()
+	}
+	End()
+}
+
+func viaLong() { long1() }
+
+func long1() { long2() }
+
+func long2() { End() }
+
+func End() {}
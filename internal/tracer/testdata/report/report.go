@@ -0,0 +1,13 @@
+package report
+
+type Point struct {
+	X, Y int
+}
+
+func scale(p Point) Point {
+	return Point{p.X * 2, p.Y * 2}
+}
+
+func Move(p Point) Point {
+	return scale(p)
+}
@@ -0,0 +1,25 @@
+package snippets
+
+// Config holds the limits applied by Entry.
+type Config struct {
+	Max int // Upper bound.
+}
+
+// Clamp limits n to the configured maximum.
+func (c Config) Clamp(n int) int {
+	if n > c.Max {
+		return c.Max
+	}
+	return n
+}
+
+// Entry doubles n up to a limit of ten.
+func Entry(n int) int {
+	c := Config{Max: 10}
+	return c.Clamp(double(n))
+}
+
+// double returns twice n.
+func double(n int) int {
+	return n * 2
+}
@@ -0,0 +1,7 @@
+package targets
+
+type List[T any] struct{}
+
+func (l *List[T]) Push(v T) {}
+
+func Push() {}
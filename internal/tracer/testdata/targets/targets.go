@@ -0,0 +1,11 @@
+package targets
+
+type UserRepo struct{}
+
+func (r *UserRepo) Get() {}
+
+type OrderRepo struct{}
+
+func (OrderRepo) Get() {}
+
+func Get() {}
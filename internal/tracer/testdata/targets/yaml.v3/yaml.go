@@ -0,0 +1,7 @@
+package yaml
+
+type Decoder struct{}
+
+func (d *Decoder) Decode() {}
+
+func Unmarshal() {}
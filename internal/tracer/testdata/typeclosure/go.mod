@@ -0,0 +1,3 @@
+module example.com/typeclosure
+
+go 1.22
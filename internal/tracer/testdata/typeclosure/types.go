@@ -0,0 +1,49 @@
+package typeclosure
+
+type User struct {
+	Embedded
+	Addr   Address
+	Tags   map[string]Tag
+	events chan Event
+}
+
+type Address struct {
+	Geo *GeoPoint
+}
+
+type GeoPoint struct {
+	Lat, Lng float64
+}
+
+type Tag string
+
+type Event struct{}
+
+type Embedded struct{}
+
+type Service interface {
+	Find(id ID) (Result, error)
+}
+
+type ID int
+
+type Result struct {
+	Items []Item
+}
+
+type Item struct{}
+
+type Handler struct{}
+
+func (h *Handler) Serve(req Request) Response { return Response{} }
+
+type Request struct{}
+
+type Response struct{}
+
+func Use() {
+	var u User
+	var s Service
+	var h Handler
+	_, _, _ = u, s, h
+}
@@ -8,19 +8,41 @@ import (
 	"go/format"
 	"go/token"
 	"go/types"
-	"strings"
 
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/packages"
 )
 
+// collectedCall is a single reference to a project function found while walking a function body.
+type collectedCall struct {
+	Func     *types.Func // The generic origin, for instantiated functions and methods.
+	Pos      token.Pos   // Position of the identifier naming the callee.
+	Kind     CallKind
+	TypeArgs []string // Type arguments of a generic callee at this site, as "T=int".
+}
+
+// collectedClosure is a function literal found while walking a function body.
+type collectedClosure struct {
+	Lit  *ast.FuncLit
+	Kind CallKind
+}
+
 // resultCollector implements the ast.Visitor interface. It traverses a function's
 // AST and collects all referenced internal functions, methods, and types.
+// Nested function literals are collected as closures but not walked; each
+// closure is analysed as a function of its own.
 type resultCollector struct {
-	Info            *types.Info
-	ProjectPackages map[string]bool // A set of package paths belonging to the user's project.
-	CalledFuncs     []*types.Func   // Stores all functions/methods found.
-	ReferencedTypes []types.Object  // Stores all types found.
+	Info              *types.Info
+	ProjectPackages   map[string]bool           // A set of package paths belonging to the user's project.
+	Externals         *externalFilter           // Dependency packages whose functions are collected; nil collects none.
+	Calls             []collectedCall           // Stores all function/method references found, with their call kind.
+	ExternalCalls     []collectedCall           // Stores the references to functions outside the project.
+	ExternalTypes     []types.Object            // Stores the types outside the project that were referenced.
+	Closures          []collectedClosure        // Stores the function literals found directly in the body.
+	ReferencedTypes   []types.Object            // Stores all types found.
+	ReferencedGlobals []types.Object            // Stores all package-level variables and constants found.
+	callIdents        map[*ast.Ident]CallKind   // Identifiers in call position, keyed to the kind of call.
+	callLits          map[*ast.FuncLit]CallKind // Function literals called in place, keyed to the kind of call.
 }
 
 // Visit is the core visitor method called for each node in the AST.
@@ -28,34 +50,143 @@ func (v *resultCollector) Visit(node ast.Node) ast.Visitor {
 	if node == nil {
 		return nil
 	}
-	ident, ok := node.(*ast.Ident)
-	if !ok {
-		return v
+	switch n := node.(type) {
+	case *ast.DeferStmt:
+		v.markCall(n.Call, CallDefer)
+	case *ast.GoStmt:
+		v.markCall(n.Call, CallGo)
+	case *ast.CallExpr:
+		v.markCall(n, CallDirect)
+	case *ast.FuncLit:
+		kind, ok := v.callLits[n]
+		if !ok {
+			kind = CallValue
+		}
+		v.Closures = append(v.Closures, collectedClosure{Lit: n, Kind: kind})
+		return nil
+	case *ast.Ident:
+		v.visitIdent(n)
 	}
+	return v
+}
+
+// markCall records the identifier or function literal naming the callee of call,
+// unless an enclosing defer or go statement has already claimed it.
+func (v *resultCollector) markCall(call *ast.CallExpr, kind CallKind) {
+	if lit, ok := ast.Unparen(call.Fun).(*ast.FuncLit); ok {
+		if v.callLits == nil {
+			v.callLits = make(map[*ast.FuncLit]CallKind)
+		}
+		if _, exists := v.callLits[lit]; !exists {
+			v.callLits[lit] = kind
+		}
+		return
+	}
+	ident := calleeIdent(call.Fun)
+	if ident == nil {
+		return
+	}
+	if v.callIdents == nil {
+		v.callIdents = make(map[*ast.Ident]CallKind)
+	}
+	if _, exists := v.callIdents[ident]; !exists {
+		v.callIdents[ident] = kind
+	}
+}
+
+// visitIdent collects the project function or type an identifier refers to.
+func (v *resultCollector) visitIdent(ident *ast.Ident) {
 	obj := v.Info.ObjectOf(ident)
 	if obj == nil || obj.Pkg() == nil {
-		return v
+		return
 	}
 	if !v.ProjectPackages[obj.Pkg().Path()] {
-		return v
+		if v.Externals == nil || !v.Externals.Match(obj.Pkg().Path()) {
+			return
+		}
+		switch obj := obj.(type) {
+		case *types.Func:
+			v.ExternalCalls = append(v.ExternalCalls, v.newCall(ident, obj))
+		case *types.TypeName:
+			if _, ok := obj.Type().(*types.TypeParam); !ok {
+				v.ExternalTypes = append(v.ExternalTypes, obj)
+			}
+		}
+		return
 	}
 	switch obj := obj.(type) {
 	case *types.Func:
-		v.CalledFuncs = append(v.CalledFuncs, obj)
+		v.Calls = append(v.Calls, v.newCall(ident, obj))
+		v.addConstraints(funcTypeParams(obj.Origin()))
 	case *types.TypeName:
+		// A type parameter is not a declaration of its own; its constraint is.
+		if tparam, ok := obj.Type().(*types.TypeParam); ok {
+			v.addConstraint(tparam)
+			return
+		}
 		v.ReferencedTypes = append(v.ReferencedTypes, obj)
+		if named, ok := obj.Type().(*types.Named); ok {
+			v.addConstraints(named.TypeParams())
+		}
+	case *types.Var, *types.Const:
+		if obj.Parent() == obj.Pkg().Scope() {
+			v.ReferencedGlobals = append(v.ReferencedGlobals, obj)
+		}
+	}
+}
+
+// newCall describes the reference to fn made by ident.
+func (v *resultCollector) newCall(ident *ast.Ident, fn *types.Func) collectedCall {
+	kind, ok := v.callIdents[ident]
+	if !ok {
+		kind = CallValue
+	}
+	return collectedCall{
+		Func:     fn.Origin(),
+		Pos:      ident.Pos(),
+		Kind:     kind,
+		TypeArgs: v.typeArguments(ident, fn),
+	}
+}
+
+// calleeIdent returns the identifier that names the function in a call's Fun
+// expression, looking through parentheses, selectors and generic instantiation.
+func calleeIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.SelectorExpr:
+			return e.Sel
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		default:
+			return nil
+		}
 	}
-	return v
 }
 
-// analysisResult holds the collected functions and types from a recursive analysis.
+// analysisResult holds the collected functions, types, globals and call edges from a recursive analysis.
 type analysisResult struct {
-	CalledFuncs     map[string]*types.Func
-	ReferencedTypes map[string]TypeInfo
+	CalledFuncs       map[string]*types.Func
+	ReferencedTypes   map[string]TypeInfo
+	ReferencedGlobals map[string]types.Object
+	Closures          map[string]AnalysisTarget // Function literals reached, keyed by closure name.
+	ExternalFuncs     map[string]*types.Func    // Functions outside the project that were called.
+	TracedExternals   map[string]bool           // External functions whose source was traced.
+	ExternalTypes     map[string]types.Object   // Types outside the project that were referenced.
+	Dependencies      *dependencySources        // Loader for dependency sources; nil unless external calls are recorded.
+	Edges             []CallEdge
+	Order             resultOrder // Declaration position and discovery order of everything above.
+	Stopped           error       // Why the analysis stopped before completion, wrapping ErrTruncated; nil if it completed.
 }
 
 // performRecursiveAnalysis contains the core logic for recursively traversing the AST.
-func performRecursiveAnalysis(initialTarget AnalysisTarget, depth int, pkgs []*packages.Package) (*analysisResult, error) {
+func performRecursiveAnalysis(initialTarget AnalysisTarget, depth int, pkgs []*packages.Package, opts Options) (*analysisResult, error) {
 	projectPackages := make(map[string]bool)
 	typePkgMap := make(map[*types.Package]*packages.Package)
 	for _, p := range pkgs {
@@ -65,36 +196,126 @@ func performRecursiveAnalysis(initialTarget AnalysisTarget, depth int, pkgs []*p
 		}
 	}
 
+	var resolver *interfaceResolver
+	if opts.ResolveInterfaces {
+		resolver = newInterfaceResolver(pkgs, projectPackages)
+	}
+	externals := newExternalFilter(opts)
+	var deps *dependencySources
+	if externals != nil {
+		deps = newDependencySources(pkgs, opts.Dependencies)
+		deps.Context = opts.Context
+	}
+
 	queue := []AnalysisTask{
 		{Target: initialTarget, Depth: 0},
 	}
-	processedFuncs := make(map[string]bool)
+	processedFuncs := make(map[string]int) // Lowest ExternalDepth each function was analyzed at.
 	allCalledFuncs := make(map[string]*types.Func)
 	allReferencedTypes := make(map[string]TypeInfo)
+	allReferencedGlobals := make(map[string]types.Object)
+	allClosures := make(map[string]AnalysisTarget)
+	allExternalFuncs := make(map[string]*types.Func)
+	tracedExternals := make(map[string]bool)
+	externalQueued := make(map[string]int) // Lowest ExternalDepth each external function was queued at.
+	allExternalTypes := make(map[string]types.Object)
+	var allEdges []CallEdge
+	order := make(resultOrder)
+	record := func(key string, obj types.Object, depth int) {
+		var position token.Position
+		if defPkg, ok := typePkgMap[obj.Pkg()]; ok {
+			position = defPkg.Fset.Position(obj.Pos())
+		}
+		order.add(key, obj.Pkg().Path(), position, depth)
+	}
 
+	var stopped error
+	lastDepth := -1
 	for len(queue) > 0 {
+		if stopped = opts.stopped(); stopped != nil {
+			break
+		}
 		currentTask := queue[0]
 		queue = queue[1:]
-		fnObj := currentTask.Target.Pkg.TypesInfo.ObjectOf(currentTask.Target.Fn.Name)
-		if fnObj == nil {
+		fnKey, err := targetKey(currentTask.Target)
+		if err != nil {
 			continue
 		}
-		fnKey := fnObj.(*types.Func).FullName()
-		if processedFuncs[fnKey] {
+		// Dependency code reached again closer to the project is analyzed once
+		// more, so that it is traced as far as the lower ExternalDepth allows;
+		// the edges it adds the second time are already recorded.
+		done, revisit := processedFuncs[fnKey]
+		if revisit && done <= currentTask.ExternalDepth {
 			continue
 		}
-		processedFuncs[fnKey] = true
+		processedFuncs[fnKey] = currentTask.ExternalDepth
+		edgeCount := len(allEdges)
+		if currentTask.Depth != lastDepth || len(processedFuncs)%progressInterval == 0 {
+			lastDepth = currentTask.Depth
+			opts.progress("Tracing depth %d of %d: %d functions analyzed, %d queued", currentTask.Depth, depth, len(processedFuncs)-1, len(queue))
+		}
 
 		collector := &resultCollector{
 			Info:            currentTask.Target.Pkg.TypesInfo,
 			ProjectPackages: projectPackages,
+			Externals:       externals,
 		}
-		ast.Walk(collector, currentTask.Target.Fn.Body)
+		if fnObj, ok := currentTask.Target.Pkg.TypesInfo.ObjectOf(currentTask.Target.Fn.Name).(*types.Func); ok && currentTask.Target.Lit == nil {
+			collector.addConstraints(funcTypeParams(fnObj))
+		}
+		ast.Walk(collector, currentTask.Target.body())
 
-		for _, fun := range collector.CalledFuncs {
+		// Closures belong to the function that declares them and are analysed
+		// at its depth, with the calls they make attributed to them.
+		for _, closure := range collector.Closures {
+			closureTarget := AnalysisTarget{Pkg: currentTask.Target.Pkg, Fn: currentTask.Target.Fn, Lit: closure.Lit}
+			closureKey, err := targetKey(closureTarget)
+			if err != nil {
+				continue
+			}
+			allEdges = append(allEdges, CallEdge{
+				Caller:   fnKey,
+				Callee:   closureKey,
+				Position: currentTask.Target.Pkg.Fset.Position(closure.Lit.Pos()).String(),
+				Depth:    currentTask.Depth,
+				Kind:     closure.Kind,
+			})
+			if _, exists := allClosures[closureKey]; !exists || revisit {
+				allClosures[closureKey] = closureTarget
+				order.add(closureKey, closureTarget.Pkg.PkgPath, closureTarget.Pkg.Fset.Position(closure.Lit.Pos()), currentTask.Depth)
+				queue = append(queue, AnalysisTask{Target: closureTarget, Depth: currentTask.Depth, ExternalDepth: currentTask.ExternalDepth})
+			}
+		}
+
+		var calledFuncs []*types.Func
+		for _, call := range collector.Calls {
+			edge := CallEdge{
+				Caller:   fnKey,
+				Callee:   call.Func.FullName(),
+				Position: currentTask.Target.Pkg.Fset.Position(call.Pos).String(),
+				Depth:    currentTask.Depth,
+				Kind:     call.Kind,
+				TypeArgs: call.TypeArgs,
+			}
+			allEdges = append(allEdges, edge)
+			calledFuncs = append(calledFuncs, call.Func)
+			if resolver == nil {
+				continue
+			}
+			for _, impl := range resolver.Implementations(call.Func) {
+				implEdge := edge
+				implEdge.Callee = impl.FullName()
+				implEdge.Via = edge.Callee
+				allEdges = append(allEdges, implEdge)
+				calledFuncs = append(calledFuncs, impl)
+			}
+		}
+
+		for _, fun := range calledFuncs {
 			funKey := fun.FullName()
 			if _, exists := allCalledFuncs[funKey]; !exists {
 				allCalledFuncs[funKey] = fun
+				record(funKey, fun, currentTask.Depth+1)
 				if currentTask.Depth < depth && projectPackages[fun.Pkg().Path()] {
 					defPkg, ok := typePkgMap[fun.Pkg()]
 					if !ok {
@@ -110,6 +331,43 @@ func performRecursiveAnalysis(initialTarget AnalysisTarget, depth int, pkgs []*p
 				}
 			}
 		}
+
+		// External functions are leaf nodes unless their source is traced,
+		// which happens up to opts.ExternalDepth levels into dependency code.
+		for _, call := range collector.ExternalCalls {
+			funKey := call.Func.FullName()
+			allEdges = append(allEdges, CallEdge{
+				Caller:   fnKey,
+				Callee:   funKey,
+				Position: currentTask.Target.Pkg.Fset.Position(call.Pos).String(),
+				Depth:    currentTask.Depth,
+				Kind:     call.Kind,
+				TypeArgs: call.TypeArgs,
+			})
+			if _, exists := allExternalFuncs[funKey]; !exists {
+				allExternalFuncs[funKey] = call.Func
+				record(funKey, call.Func, currentTask.Depth+1)
+			}
+			if opts.External != ExternalTrace || currentTask.ExternalDepth >= opts.ExternalDepth {
+				continue
+			}
+			// A function first reached deeper in dependency code, or at the
+			// ExternalDepth limit, is traced again when reached closer to the project.
+			if queued, ok := externalQueued[funKey]; ok && queued <= currentTask.ExternalDepth+1 {
+				continue
+			}
+			externalQueued[funKey] = currentTask.ExternalDepth + 1
+			defPkg, defNode := deps.FuncDecl(call.Func)
+			if defNode == nil || defNode.Body == nil {
+				continue
+			}
+			tracedExternals[funKey] = true
+			queue = append(queue, AnalysisTask{
+				Target:        AnalysisTarget{Pkg: defPkg, Fn: defNode},
+				Depth:         currentTask.Depth + 1,
+				ExternalDepth: currentTask.ExternalDepth + 1,
+			})
+		}
 		for _, typeObj := range collector.ReferencedTypes {
 			typeKey := fmt.Sprintf("%s.%s", typeObj.Pkg().Path(), typeObj.Name())
 			if _, exists := allReferencedTypes[typeKey]; !exists {
@@ -117,97 +375,72 @@ func performRecursiveAnalysis(initialTarget AnalysisTarget, depth int, pkgs []*p
 					Name:       typeKey,
 					Definition: typeObj,
 				}
+				record(typeKey, typeObj, currentTask.Depth)
+			}
+		}
+		for _, typeObj := range collector.ExternalTypes {
+			typeKey := fmt.Sprintf("%s.%s", typeObj.Pkg().Path(), typeObj.Name())
+			if _, exists := allExternalTypes[typeKey]; !exists {
+				allExternalTypes[typeKey] = typeObj
+				record(typeKey, typeObj, currentTask.Depth)
+			}
+		}
+		for _, globalObj := range collector.ReferencedGlobals {
+			globalKey := fmt.Sprintf("%s.%s", globalObj.Pkg().Path(), globalObj.Name())
+			if _, exists := allReferencedGlobals[globalKey]; !exists {
+				allReferencedGlobals[globalKey] = globalObj
+				record(globalKey, globalObj, currentTask.Depth)
 			}
 		}
+		if revisit {
+			allEdges = allEdges[:edgeCount]
+		}
+	}
+
+	opts.progress("Traced %d functions", len(processedFuncs))
+	if opts.TypeDepth > 0 && stopped == nil {
+		opts.progress("Expanding %d referenced types to type depth %d", len(allReferencedTypes), opts.TypeDepth)
+		initialTypes := orderedKeys(allReferencedTypes, order, OrderDiscovery)
+		for _, typeKey := range expandTypeClosure(allReferencedTypes, initialTypes, opts.TypeDepth, projectPackages) {
+			// Types found through other types rank just past the deepest traced function.
+			record(typeKey, allReferencedTypes[typeKey].Definition, depth+1)
+		}
 	}
 
 	return &analysisResult{
-		CalledFuncs:     allCalledFuncs,
-		ReferencedTypes: allReferencedTypes,
+		CalledFuncs:       allCalledFuncs,
+		ReferencedTypes:   allReferencedTypes,
+		ReferencedGlobals: allReferencedGlobals,
+		Closures:          allClosures,
+		ExternalFuncs:     allExternalFuncs,
+		TracedExternals:   tracedExternals,
+		ExternalTypes:     allExternalTypes,
+		Dependencies:      deps,
+		Edges:             allEdges,
+		Order:             order,
+		Stopped:           stopped,
 	}, nil
 }
 
-// Analyze performs the recursive code analysis and returns a formatted report.
-func Analyze(initialTarget AnalysisTarget, initialFile string, depth int, pkgs []*packages.Package) (string, error) {
-	results, err := performRecursiveAnalysis(initialTarget, depth, pkgs)
-	if err != nil {
-		return "", err
+// Analyze performs the recursive code analysis and returns a report rendered in
+// opts.Format, trimmed to the size budget in opts if one is set.
+func Analyze(initialTarget AnalysisTarget, initialFile string, depth int, pkgs []*packages.Package, opts Options) (string, error) {
+	if opts.Format == FormatDOT || opts.Format == FormatMermaid {
+		return RenderCallGraph(initialTarget, depth, pkgs, opts)
 	}
-
-	typePkgMap := make(map[*types.Package]*packages.Package)
-	for _, p := range pkgs {
-		if p.Types != nil {
-			typePkgMap[p.Types] = p
-		}
-	}
-
-	// --- Report Generation ---
-	var report strings.Builder
-	report.WriteString(fmt.Sprintf("Analysis for Function: %s (depth=%d)\n", initialTarget.Fn.Name.Name, depth))
-	report.WriteString(fmt.Sprintf("Defined in: %s\n", initialFile))
-
-	report.WriteString("\n--- Target Function Source Code ---\n")
-	initialSnippet, err := getFuncSourceSnippet(initialTarget.Pkg, initialTarget.Fn.Name.Pos())
+	report, err := BuildReport(initialTarget, initialFile, depth, pkgs, opts)
 	if err != nil {
-		report.WriteString(fmt.Sprintf("// Error getting source: %v\n", err))
-	} else {
-		report.WriteString(initialSnippet + "\n")
-	}
-
-	report.WriteString("\n--- Summary of Dependencies ---\n")
-	report.WriteString("Called Functions/Methods:\n")
-	if len(results.CalledFuncs) > 0 {
-		for name := range results.CalledFuncs {
-			report.WriteString(fmt.Sprintf("- %s\n", name))
-		}
-	} else {
-		report.WriteString("- None\n")
-	}
-
-	report.WriteString("\nReferenced Types:\n")
-	if len(results.ReferencedTypes) > 0 {
-		for name := range results.ReferencedTypes {
-			report.WriteString(fmt.Sprintf("- %s\n", name))
-		}
-	} else {
-		report.WriteString("- None\n")
-	}
-
-	report.WriteString("\n--- Code Snippets of Dependencies ---\n")
-	if len(results.CalledFuncs) > 0 {
-		for name, fun := range results.CalledFuncs {
-			defPkg, ok := typePkgMap[fun.Pkg()]
-			if !ok {
-				continue
-			}
-			snippet, err := getFuncSourceSnippet(defPkg, fun.Pos())
-			if err == nil {
-				report.WriteString(fmt.Sprintf("\n// Source for: %s\n", name))
-				report.WriteString(fmt.Sprintf("// Defined in: %s\n", defPkg.Fset.File(fun.Pos()).Name()))
-				report.WriteString("// --------------------------------------------------\n")
-				report.WriteString(snippet + "\n")
-			}
-		}
+		return "", err
 	}
-
-	if len(results.ReferencedTypes) > 0 {
-		for name, info := range results.ReferencedTypes {
-			defPkg := typePkgMap[info.Definition.Pkg()]
-			snippet, err := getTypeSourceSnippet(defPkg, info.Definition.Pos())
-			if err == nil {
-				report.WriteString(fmt.Sprintf("\n// Source for: %s\n", name))
-				report.WriteString(fmt.Sprintf("// Defined in: %s\n", defPkg.Fset.File(info.Definition.Pos()).Name()))
-				report.WriteString("// --------------------------------------------------\n")
-				report.WriteString(snippet + "\n")
-			}
-		}
+	if err := FitReport(report, opts); err != nil {
+		return "", err
 	}
-	return report.String(), nil
+	opts.progress("Rendering report")
+	return RenderReport(report, opts)
 }
 
-// (Helper functions findFuncDeclAt, getFuncSourceSnippet, getTypeSourceSnippet are now un-exported)
+// findFuncDeclAt returns the function declaration whose name is at pos, or nil.
 func findFuncDeclAt(pkg *packages.Package, pos token.Pos) *ast.FuncDecl {
-	// ... (implementation is identical to original)
 	for _, fileAST := range pkg.Syntax {
 		if fileAST.Pos() <= pos && pos < fileAST.End() {
 			var foundNode *ast.FuncDecl
@@ -226,22 +459,39 @@ func findFuncDeclAt(pkg *packages.Package, pos token.Pos) *ast.FuncDecl {
 	return nil
 }
 
+// getFuncSourceSnippet returns the source of the function declared at pos,
+// with its comments.
 func getFuncSourceSnippet(pkg *packages.Package, pos token.Pos) (string, error) {
-	// ... (implementation is identical to original)
 	node := findFuncDeclAt(pkg, pos)
 	if node == nil {
-		return "", fmt.Errorf("could not find FuncDecl node at position %d", pos)
+		return "", fmt.Errorf("could not find FuncDecl node at %s", pkg.Fset.Position(pos))
+	}
+	return formatWithComments(pkg, node)
+}
+
+// getFuncSignatureSnippet returns the declaration of a function without its body,
+// with or without its doc comment.
+func getFuncSignatureSnippet(pkg *packages.Package, pos token.Pos, withDoc bool) (string, error) {
+	node := findFuncDeclAt(pkg, pos)
+	if node == nil {
+		return "", fmt.Errorf("could not find FuncDecl node at %s", pkg.Fset.Position(pos))
+	}
+	signature := *node
+	signature.Body = nil
+	if !withDoc {
+		signature.Doc = nil
 	}
 	var buf bytes.Buffer
-	err := format.Node(&buf, pkg.Fset, node)
+	err := format.Node(&buf, pkg.Fset, &signature)
 	if err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
-func getTypeSourceSnippet(pkg *packages.Package, pos token.Pos) (string, error) {
-	// ... (implementation is identical to original)
+// findTypeDeclAt returns the declaration of the type whose name is at pos: the
+// enclosing `type` GenDecl if there is one, otherwise the TypeSpec itself.
+func findTypeDeclAt(pkg *packages.Package, pos token.Pos) ast.Node {
 	for _, fileAST := range pkg.Syntax {
 		if fileAST.Pos() <= pos && pos < fileAST.End() {
 			var foundNode ast.Node
@@ -253,76 +503,145 @@ func getTypeSourceSnippet(pkg *packages.Package, pos token.Pos) (string, error)
 				return true
 			})
 			if foundNode != nil {
-				var buf bytes.Buffer
-				var parentNode ast.Node = foundNode
 				path, _ := astutil.PathEnclosingInterval(fileAST, foundNode.Pos(), foundNode.End())
-
 				for _, pnode := range path {
 					if gd, ok := pnode.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
-						parentNode = gd
-						break
+						return gd
 					}
 				}
-
-				err := format.Node(&buf, pkg.Fset, parentNode)
-				if err != nil {
-					return "", err
-				}
-				return buf.String(), nil
+				return foundNode
 			}
 		}
 	}
-	return "", fmt.Errorf("could not find TypeSpec node at position %d", pos)
+	return nil
 }
 
+func getTypeSourceSnippet(pkg *packages.Package, pos token.Pos) (string, error) {
+	node := findTypeDeclAt(pkg, pos)
+	if node == nil {
+		return "", fmt.Errorf("could not find TypeSpec node at %s", pkg.Fset.Position(pos))
+	}
+	return formatWithComments(pkg, node)
+}
 
-// FindTarget locates the target function declaration within the loaded packages.
-func FindTarget(pkgs []*packages.Package, filePath, funcName string) (AnalysisTarget, error) {
-	var target AnalysisTarget
-	for _, p := range pkgs {
-		for i, file := range p.GoFiles {
-			if file == filePath {
-				for _, decl := range p.Syntax[i].Decls {
-					if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == funcName {
-						target = AnalysisTarget{Pkg: p, Fn: fn}
-						return target, nil
+// findValueDeclAt returns the declaration of the package-level variable or
+// constant whose name is at pos: the enclosing `var` or `const` GenDecl if there
+// is one, otherwise the ValueSpec itself.
+func findValueDeclAt(pkg *packages.Package, pos token.Pos) ast.Node {
+	for _, fileAST := range pkg.Syntax {
+		if fileAST.Pos() <= pos && pos < fileAST.End() {
+			var foundNode ast.Node
+			ast.Inspect(fileAST, func(n ast.Node) bool {
+				if vs, ok := n.(*ast.ValueSpec); ok {
+					for _, name := range vs.Names {
+						if name.Pos() == pos {
+							foundNode = vs
+							return false
+						}
 					}
 				}
+				return foundNode == nil
+			})
+			if foundNode != nil {
+				path, _ := astutil.PathEnclosingInterval(fileAST, foundNode.Pos(), foundNode.End())
+				for _, pnode := range path {
+					if gd, ok := pnode.(*ast.GenDecl); ok && (gd.Tok == token.VAR || gd.Tok == token.CONST) {
+						return gd
+					}
+				}
+				return foundNode
 			}
 		}
 	}
-	return target, fmt.Errorf("function '%s' not found in file '%s'", funcName, filePath)
+	return nil
+}
+
+func getValueSourceSnippet(pkg *packages.Package, pos token.Pos) (string, error) {
+	node := findValueDeclAt(pkg, pos)
+	if node == nil {
+		return "", fmt.Errorf("could not find ValueSpec node at %s", pkg.Fset.Position(pos))
+	}
+	return formatWithComments(pkg, node)
 }
 
 // GetFuncCode returns the source code of a specific function.
 func GetFuncCode(target AnalysisTarget) (string, error) {
+	if target.Lit != nil {
+		return getClosureSourceSnippet(target.Pkg, target.Lit)
+	}
 	return getFuncSourceSnippet(target.Pkg, target.Fn.Name.Pos())
 }
 
 // ExtractTypes finds all referenced types within a function, with optional recursion.
-func ExtractTypes(target AnalysisTarget, depth int, pkgs []*packages.Package) ([]string, error) {
-	results, err := performRecursiveAnalysis(target, depth, pkgs)
+func ExtractTypes(target AnalysisTarget, depth int, pkgs []*packages.Package, opts Options) ([]string, error) {
+	results, err := performRecursiveAnalysis(target, depth, pkgs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return orderedKeys(results.ReferencedTypes, results.Order, opts.Order), results.Stopped
+}
+
+// ExtractTypeSymbols finds all referenced types within a function like ExtractTypes,
+// and describes each one with its source and, if opts.Methods is set, its methods.
+func ExtractTypeSymbols(target AnalysisTarget, depth int, pkgs []*packages.Package, opts Options) ([]Symbol, error) {
+	results, err := performRecursiveAnalysis(target, depth, pkgs, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	var typeNames []string
-	for name := range results.ReferencedTypes {
-		typeNames = append(typeNames, name)
+	typePkgMap := make(map[*types.Package]*packages.Package)
+	for _, p := range pkgs {
+		if p.Types != nil {
+			typePkgMap[p.Types] = p
+		}
+	}
+	typeSymbols := []Symbol{}
+	for _, name := range orderedKeys(results.ReferencedTypes, results.Order, opts.Order) {
+		info := results.ReferencedTypes[name]
+		defPkg := typePkgMap[info.Definition.Pkg()]
+		sym := newTypeSymbol(defPkg, info.Definition)
+		sym.Methods = typeMethods(info.Definition, typePkgMap, opts.Methods)
+		typeSymbols = append(typeSymbols, sym)
+	}
+	return typeSymbols, results.Stopped
+}
+
+// ExtractGlobals finds all package-level variables and constants referenced within a function, with optional recursion.
+func ExtractGlobals(target AnalysisTarget, depth int, pkgs []*packages.Package, opts Options) ([]string, error) {
+	results, err := performRecursiveAnalysis(target, depth, pkgs, opts)
+	if err != nil {
+		return nil, err
 	}
-	return typeNames, nil
+
+	return orderedKeys(results.ReferencedGlobals, results.Order, opts.Order), results.Stopped
 }
 
 // ExtractCalledFuncs finds all functions and methods called by a function, with optional recursion.
-func ExtractCalledFuncs(target AnalysisTarget, depth int, pkgs []*packages.Package) ([]string, error) {
-	results, err := performRecursiveAnalysis(target, depth, pkgs)
+func ExtractCalledFuncs(target AnalysisTarget, depth int, pkgs []*packages.Package, opts Options) ([]string, error) {
+	results, err := performRecursiveAnalysis(target, depth, pkgs, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	var funcNames []string
-	for name := range results.CalledFuncs {
+	funcNames := orderedKeys(results.CalledFuncs, results.Order, opts.Order)
+	for name := range results.Closures {
+		funcNames = append(funcNames, name)
+	}
+	for name := range results.ExternalFuncs {
 		funcNames = append(funcNames, name)
 	}
-	return funcNames, nil
-}
\ No newline at end of file
+	return results.Order.sort(funcNames, opts.Order), results.Stopped
+}
+
+// ExtractCallGraph returns the caller -> callee edges reachable from a function, with optional recursion.
+func ExtractCallGraph(target AnalysisTarget, depth int, pkgs []*packages.Package, opts Options) (*CallGraph, error) {
+	results, err := performRecursiveAnalysis(target, depth, pkgs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &CallGraph{Edges: results.Edges, Truncated: truncation(results.Stopped)}
+	graph.Root, _ = targetKey(target)
+	return graph, nil
+}
@@ -0,0 +1,127 @@
+// internal/tracer/typeclosure.go
+package tracer
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// expandTypeClosure adds to referencedTypes every project type reachable from
+// them through struct fields, embedded types, element types, method signatures
+// and interface methods. Types named directly in function bodies are at level
+// 0 and expanded in the order given by initial; types at levels below
+// typeDepth are expanded. The keys of the added types are returned in the
+// order they were found.
+func expandTypeClosure(referencedTypes map[string]TypeInfo, initial []string, typeDepth int, projectPackages map[string]bool) []string {
+	type typeTask struct {
+		Obj   types.Object
+		Level int
+	}
+	var queue []typeTask
+	for _, typeKey := range initial {
+		queue = append(queue, typeTask{Obj: referencedTypes[typeKey].Definition, Level: 0})
+	}
+	var added []string
+
+	for len(queue) > 0 {
+		currentTask := queue[0]
+		queue = queue[1:]
+		if currentTask.Level >= typeDepth {
+			continue
+		}
+		for _, typeObj := range typeDependencies(currentTask.Obj) {
+			if typeObj.Pkg() == nil || !projectPackages[typeObj.Pkg().Path()] {
+				continue
+			}
+			typeKey := fmt.Sprintf("%s.%s", typeObj.Pkg().Path(), typeObj.Name())
+			if _, exists := referencedTypes[typeKey]; exists {
+				continue
+			}
+			referencedTypes[typeKey] = TypeInfo{
+				Name:       typeKey,
+				Definition: typeObj,
+			}
+			added = append(added, typeKey)
+			queue = append(queue, typeTask{Obj: typeObj, Level: currentTask.Level + 1})
+		}
+	}
+	return added
+}
+
+// typeDependencies returns the named types used in the definition of a type:
+// in its type parameter constraints, its underlying structure and the
+// signatures of its declared methods.
+func typeDependencies(obj types.Object) []*types.TypeName {
+	var deps []*types.TypeName
+	seen := make(map[*types.TypeName]bool)
+	emit := func(typeName *types.TypeName) {
+		if typeName != obj && !seen[typeName] {
+			seen[typeName] = true
+			deps = append(deps, typeName)
+		}
+	}
+
+	switch t := obj.Type().(type) {
+	case *types.Named:
+		for i := 0; i < t.TypeParams().Len(); i++ {
+			collectNamedTypes(t.TypeParams().At(i).Constraint(), emit)
+		}
+		collectNamedTypes(t.Underlying(), emit)
+		for i := 0; i < t.NumMethods(); i++ {
+			collectNamedTypes(t.Method(i).Type(), emit)
+		}
+	case *types.Alias:
+		collectNamedTypes(types.Unalias(t), emit)
+	}
+	return deps
+}
+
+// collectNamedTypes walks a type expression and calls emit for every named type
+// it mentions. Named types are not expanded further; that happens one level later.
+func collectNamedTypes(t types.Type, emit func(*types.TypeName)) {
+	switch t := t.(type) {
+	case *types.Named:
+		emit(t.Origin().Obj())
+		if args := t.TypeArgs(); args != nil {
+			for i := 0; i < args.Len(); i++ {
+				collectNamedTypes(args.At(i), emit)
+			}
+		}
+	case *types.Alias:
+		emit(t.Obj())
+		collectNamedTypes(types.Unalias(t), emit)
+	case *types.Pointer:
+		collectNamedTypes(t.Elem(), emit)
+	case *types.Slice:
+		collectNamedTypes(t.Elem(), emit)
+	case *types.Array:
+		collectNamedTypes(t.Elem(), emit)
+	case *types.Chan:
+		collectNamedTypes(t.Elem(), emit)
+	case *types.Map:
+		collectNamedTypes(t.Key(), emit)
+		collectNamedTypes(t.Elem(), emit)
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			collectNamedTypes(t.At(i).Type(), emit)
+		}
+	case *types.Signature:
+		collectNamedTypes(t.Params(), emit)
+		collectNamedTypes(t.Results(), emit)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			collectNamedTypes(t.Field(i).Type(), emit)
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			collectNamedTypes(t.EmbeddedType(i), emit)
+		}
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			collectNamedTypes(t.ExplicitMethod(i).Type(), emit)
+		}
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			collectNamedTypes(t.Term(i).Type(), emit)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package tracer
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestTypeClosure(t *testing.T) {
+	pkgs := loadFixture(t, "typeclosure")
+	use := fixtureTarget(t, pkgs, "typeclosure", "types.go", "Use")
+
+	direct := []string{"Handler", "Service", "User"}
+	level1 := append([]string{"Address", "Embedded", "Event", "ID", "Request", "Response", "Result", "Tag"}, direct...)
+	level2 := append([]string{"GeoPoint", "Item"}, level1...)
+	tests := []struct {
+		typeDepth int
+		want      []string
+	}{
+		{0, direct},
+		{1, level1},
+		{2, level2},
+		{5, level2},
+	}
+	for _, tt := range tests {
+		got, err := ExtractTypes(use, 0, pkgs, Options{TypeDepth: tt.typeDepth})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, name := range got {
+			got[i] = strings.TrimPrefix(name, "example.com/typeclosure.")
+		}
+		sort.Strings(got)
+		want := append([]string(nil), tt.want...)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ExtractTypes(type depth %d) = %q, want %q", tt.typeDepth, got, want)
+		}
+	}
+}
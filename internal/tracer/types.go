@@ -2,22 +2,28 @@
 package tracer
 
 import (
+	"context"
+	"fmt"
 	"go/ast"
 	"go/types"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
 
-// AnalysisTarget represents a function or method to be analyzed.
+// AnalysisTarget represents a function or method to be analyzed. When Lit is
+// set, the target is that function literal inside Fn rather than Fn itself.
 type AnalysisTarget struct {
 	Pkg *packages.Package
 	Fn  *ast.FuncDecl
+	Lit *ast.FuncLit
 }
 
 // AnalysisTask represents a task in the analysis work queue.
 type AnalysisTask struct {
-	Target AnalysisTarget
-	Depth  int
+	Target        AnalysisTarget
+	Depth         int
+	ExternalDepth int // Levels into dependency code; 0 for project functions.
 }
 
 // TypeInfo stores information about a discovered type definition.
@@ -25,4 +31,111 @@ type TypeInfo struct {
 	Name       string
 	Definition types.Object
 	Snippet    string
-}
\ No newline at end of file
+}
+
+// CallKind describes how a callee is reached from a call site.
+type CallKind string
+
+const (
+	CallDirect CallKind = "direct" // An ordinary call expression.
+	CallValue  CallKind = "value"  // A function or method value that is referenced but not called in place.
+	CallDefer  CallKind = "defer"  // A call in a defer statement.
+	CallGo     CallKind = "go"     // A call started as a goroutine.
+)
+
+// CallEdge represents a single caller -> callee relationship found during analysis.
+type CallEdge struct {
+	Caller   string   `json:"caller"`
+	Callee   string   `json:"callee"`
+	Position string   `json:"position"` // Call site as file:line:col.
+	Depth    int      `json:"depth"`    // Analysis depth of the caller (0 for the target).
+	Kind     CallKind `json:"kind"`
+	Via      string   `json:"via,omitempty"`       // Interface method the call was resolved through, if any.
+	TypeArgs []string `json:"type_args,omitempty"` // Type arguments of a generic callee, e.g. "T=int".
+}
+
+// String formats the edge as a single human-readable line.
+func (e CallEdge) String() string {
+	line := fmt.Sprintf("%s -> %s [%s] at %s (depth=%d)", e.Caller, e.Callee, e.Kind, e.Position, e.Depth)
+	if e.Via != "" {
+		line += fmt.Sprintf(" via %s", e.Via)
+	}
+	if len(e.TypeArgs) > 0 {
+		line += fmt.Sprintf(" with [%s]", strings.Join(e.TypeArgs, ", "))
+	}
+	return line
+}
+
+// CallGraph is the set of call edges reachable from a root function.
+type CallGraph struct {
+	Root      string     `json:"root"`
+	Edges     []CallEdge `json:"edges"`
+	Truncated string     `json:"truncated,omitempty"` // Why the trace stopped before completion, if it did.
+}
+
+// MethodMode selects whether and how the methods of referenced types are included.
+type MethodMode string
+
+const (
+	MethodsNone       MethodMode = ""           // Methods are not included.
+	MethodsSignatures MethodMode = "signatures" // Only method signatures are included.
+	MethodsFull       MethodMode = "full"       // Methods are included with their bodies.
+)
+
+// ExternalMode selects whether calls into packages outside the project are recorded.
+type ExternalMode string
+
+const (
+	ExternalNone  ExternalMode = ""      // Calls outside the project are ignored.
+	ExternalLeaf  ExternalMode = "leaf"  // External functions are recorded as leaf nodes with their signature and doc comment.
+	ExternalTrace ExternalMode = "trace" // External functions are also traced into their source, up to ExternalDepth levels.
+)
+
+// SnippetMode selects how much of each function's source a report shows.
+type SnippetMode string
+
+const (
+	SnippetsFull     SnippetMode = ""         // Every function is shown with its body.
+	SnippetsSkeleton SnippetMode = "skeleton" // Functions and methods are shown as doc comment and signature only.
+	SnippetsHybrid   SnippetMode = "hybrid"   // The target is shown in full, its dependencies as in SnippetsSkeleton.
+)
+
+// OrderMode selects the order in which collected functions, types and variables are listed.
+type OrderMode string
+
+const (
+	OrderPosition  OrderMode = "position"  // By package path, then file, then position in the file. The zero value orders the same way.
+	OrderDiscovery OrderMode = "discovery" // In the breadth-first order in which the analysis reached them.
+)
+
+// Options controls optional behaviour of the analysis.
+type Options struct {
+	ResolveInterfaces bool         // Resolve interface method calls to all concrete project implementations.
+	IncludeEdges      bool         // Include the caller -> callee edges in reports.
+	Format            string       // Report format: FormatText (default), FormatJSON, FormatDOT or FormatMermaid.
+	Methods           MethodMode   // Attach the declared methods of each referenced project type.
+	TypeDepth         int          // Levels of type dependencies (fields, elements, method signatures) to add to referenced types; 0 disables.
+	External          ExternalMode // Record calls into the standard library and third-party dependencies.
+	ExternalDepth     int          // Levels of dependency functions whose source is traced when External is ExternalTrace.
+	ExternalAllow     []string     // Dependency package patterns to follow, e.g. "net/http" or "github.com/jackc/pgx/..."; empty allows all.
+	ExternalDeny      []string     // Dependency package patterns never to follow; takes precedence over ExternalAllow.
+	Order             OrderMode    // Order of functions, types and variables in results; defaults to OrderPosition.
+	MaxBytes          int          // Size budget of a rendered report in bytes; 0 means unlimited.
+	MaxTokens         int          // Size budget of a rendered report in estimated tokens; 0 means unlimited.
+	Snippets          SnippetMode  // How much of each function's source reports show; defaults to SnippetsFull.
+	StripComments     bool         // Leave doc, field and inline comments out of report snippets.
+
+	// Dependencies shares the dependency sources loaded to describe and trace
+	// external functions across analyses of the same project; if nil, every
+	// analysis loads its own.
+	Dependencies *DependencyCache
+
+	// Context stops a running analysis when it is cancelled or its deadline
+	// passes; the results gathered so far are returned as truncated. A nil
+	// Context never stops an analysis.
+	Context context.Context
+	// Progress, if set, receives a short status message as the analysis moves
+	// through its stages: the depth being traced, the number of functions
+	// analyzed and queued, and the rendering of the result.
+	Progress func(message string)
+}